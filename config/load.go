@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Load собирает настройки из источников по возрастанию приоритета: значения по умолчанию,
+// YAML-файл из CONFIG_FILE (если задан), файл .env и переменные окружения — и проверяет их.
+//
+// Секреты можно передавать через файлы: если задана переменная KEY_FILE, значение KEY
+// читается из указанного файла (например, JWT_SECRET_FILE=/run/secrets/jwt_secret).
+func Load() (*Config, error) {
+	// .env не переопределяет уже заданные переменные окружения и в production может отсутствовать
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка загрузки .env: %w", err)
+	}
+
+	cfg := Defaults()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadYAML(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	if err := loadRateLimits(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректная конфигурация:\n%w", err)
+	}
+	return cfg, nil
+}
+
+func loadYAML(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookupEnv возвращает значение переменной key или содержимое файла из key_FILE.
+func lookupEnv(key string) (string, bool, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true, nil
+	}
+	path, ok := os.LookupEnv(key + "_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// loadEnv заполняет поля с тегом env из переменных окружения, обходя вложенные структуры.
+// Пустая переменная считается незаданной.
+func loadEnv(v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, sf := v.Field(i), v.Type().Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			if err := loadEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+		key := sf.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		value, ok, err := lookupEnv(key)
+		if err != nil {
+			return err
+		}
+		if !ok || value == "" {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("некорректное значение %s=%q: %w", key, value, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s", field.Type())
+	}
+	return nil
+}
+
+// loadRateLimits читает лимиты групп маршрутов из переменных RATE_LIMIT_<GROUP>.
+func loadRateLimits(cfg *Config) error {
+	if cfg.RateLimit.Limits == nil {
+		cfg.RateLimit.Limits = map[string]Rate{}
+	}
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		group, ok := strings.CutPrefix(key, "RATE_LIMIT_")
+		if !ok || value == "" || group == "ENABLED" || group == "STORE" || strings.HasSuffix(group, "_FILE") {
+			continue
+		}
+		var rate Rate
+		if err := rate.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		cfg.RateLimit.Limits[strings.ToLower(group)] = rate
+	}
+	return nil
+}
@@ -1,38 +1,128 @@
 package db
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
+	"strings"
 
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/logging"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var (
-	DB    *gorm.DB
-	SqlDB *sql.DB
-)
+// Open открывает подключение к Postgres или SQLite, настраивает пул соединений и проверяет,
+// что база доступна.
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения SQL DB: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("база данных недоступна: %w", err)
+	}
+	return db, nil
+}
+
+// Close закрывает пул соединений подключения database.
+func Close(database *gorm.DB) error {
+	sqlDB, err := database.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// IsDuplicate сообщает, что err — нарушение уникального индекса. Ошибку драйвера распознает
+// диалект подключения tx, поэтому проверка одинаково работает с Postgres и SQLite.
+func IsDuplicate(tx *gorm.DB, err error) bool {
+	if translator, ok := tx.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+func dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver() {
+	case config.DriverPostgres:
+		dsn, err := postgresDSN(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return postgres.Open(dsn), nil
+	case config.DriverSQLite:
+		return sqlite.Open(sqliteDSN(cfg.URL)), nil
+	}
+	return nil, fmt.Errorf("неизвестный драйвер базы данных в DATABASE_URL %q", cfg.URL)
+}
+
+// postgresDSN возвращает строку подключения к Postgres. Параметры TLS из настроек дополняют URL,
+// но не переопределяют заданные в нем явно.
+func postgresDSN(cfg config.DatabaseConfig) (string, error) {
+	tls := [][2]string{
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	}
 
-func Connect() {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"))
+	if cfg.URL == "" {
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
+			cfg.Host,
+			cfg.User,
+			cfg.Password,
+			cfg.Name,
+			cfg.Port)
+		for _, param := range tls {
+			if param[1] != "" {
+				dsn += fmt.Sprintf(" %s=%s", param[0], param[1])
+			}
+		}
+		return dsn, nil
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	u, err := url.Parse(cfg.URL)
 	if err != nil {
-		panic(fmt.Errorf("ошибка подключения к базе данных: %w", err))
+		return "", fmt.Errorf("некорректный DATABASE_URL: %w", err)
+	}
+	query := u.Query()
+	for _, param := range tls {
+		if param[1] != "" && !query.Has(param[0]) {
+			query.Set(param[0], param[1])
+		}
 	}
-	DB = db
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
 
-	if sqlDB, err := db.DB(); err != nil {
-		panic(fmt.Errorf("ошибка получения SQL DB: %w", err))
-	} else {
-		SqlDB = sqlDB
+// sqliteDSN переводит sqlite:///abs/path.db или sqlite://rel/path.db в строку подключения go-sqlite3.
+// Ожидание блокировки, WAL и внешние ключи включаются по умолчанию: без них конкурентные запросы
+// получают «database is locked», а каскадное удаление не работает.
+func sqliteDSN(rawURL string) string {
+	path := rawURL[strings.Index(rawURL, ":")+1:]
+	path = strings.TrimPrefix(path, "//")
+	path, rawQuery, _ := strings.Cut(path, "?")
 
-		fmt.Println("Успешное подключение к базе данных")
+	query, _ := url.ParseQuery(rawQuery)
+	for key, value := range map[string]string{"_busy_timeout": "5000", "_journal_mode": "WAL", "_foreign_keys": "on"} {
+		if !query.Has(key) {
+			query.Set(key, value)
+		}
 	}
+	return "file:" + path + "?" + query.Encode()
 }
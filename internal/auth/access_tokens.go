@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAccessToken — персональный токен не найден или истек.
+var ErrInvalidAccessToken = errors.New("invalid personal access token")
+
+// lastUsedPrecision — с какой точностью обновляется время последнего использования токена,
+// чтобы не писать в базу на каждый запрос.
+const lastUsedPrecision = time.Minute
+
+// CreatePersonalAccessToken выпускает персональный токен и возвращает его в открытом виде.
+func CreatePersonalAccessToken(tx *gorm.DB, userID uint, input models.PersonalAccessTokenInput) (string, models.PersonalAccessToken, error) {
+	random, err := utils.RandomToken(32)
+	if err != nil {
+		return "", models.PersonalAccessToken{}, err
+	}
+	token := models.PersonalAccessTokenPrefix + random
+	pat := models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      input.Name,
+		TokenHash: utils.HashToken(token),
+		Prefix:    token[:len(models.PersonalAccessTokenPrefix)+6],
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	return token, pat, tx.Create(&pat).Error
+}
+
+// AuthenticatePersonalAccessToken находит действующий персональный токен и отмечает его использование.
+func AuthenticatePersonalAccessToken(tx *gorm.DB, token string) (models.PersonalAccessToken, error) {
+	var pat models.PersonalAccessToken
+	if err := tx.Where("token_hash = ?", utils.HashToken(token)).First(&pat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pat, ErrInvalidAccessToken
+		}
+		return pat, err
+	}
+	now := time.Now()
+	if pat.ExpiresAt != nil && !pat.ExpiresAt.After(now) {
+		return pat, ErrInvalidAccessToken
+	}
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= lastUsedPrecision {
+		if err := tx.Model(&pat).UpdateColumn("last_used_at", now).Error; err != nil {
+			return pat, err
+		}
+	}
+	return pat, nil
+}
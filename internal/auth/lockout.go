@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// LoginPolicy — параметры защиты входа от перебора паролей.
+type LoginPolicy struct {
+	// MaxFailures — после скольких неудачных попыток подряд учетная запись блокируется.
+	MaxFailures int
+	// LockoutBase — срок первой блокировки; каждая следующая неудача удваивает его до LockoutMax.
+	LockoutBase time.Duration
+	LockoutMax  time.Duration
+	// IPMaxFailures — сколько неудачных попыток допускается с одного IP за IPWindow.
+	IPMaxFailures int
+	IPWindow      time.Duration
+}
+
+// NewLoginPolicy возвращает параметры защиты из настроек: LOGIN_MAX_FAILURES (по умолчанию 5),
+// LOGIN_LOCKOUT_BASE (1m), LOGIN_LOCKOUT_MAX (1h), LOGIN_IP_MAX_FAILURES (20), LOGIN_IP_WINDOW (15m).
+func NewLoginPolicy(login config.LoginConfig) LoginPolicy {
+	return LoginPolicy{
+		MaxFailures:   login.MaxFailures,
+		LockoutBase:   login.LockoutBase,
+		LockoutMax:    login.LockoutMax,
+		IPMaxFailures: login.IPMaxFailures,
+		IPWindow:      login.IPWindow,
+	}
+}
+
+// lockoutDuration возвращает срок блокировки после failures неудачных попыток подряд
+// или 0, если порог еще не достигнут.
+func (p LoginPolicy) lockoutDuration(failures int) time.Duration {
+	if failures < p.MaxFailures {
+		return 0
+	}
+	d := p.LockoutBase
+	for i := p.MaxFailures; i < failures && d < p.LockoutMax; i++ {
+		d *= 2
+	}
+	if d > p.LockoutMax {
+		d = p.LockoutMax
+	}
+	return d
+}
+
+// LockoutRemaining возвращает, сколько еще действует блокировка учетной записи (0, если её нет).
+func LockoutRemaining(user models.User, now time.Time) time.Duration {
+	if user.LockedUntil == nil || !user.LockedUntil.After(now) {
+		return 0
+	}
+	return user.LockedUntil.Sub(now)
+}
+
+// failedResults — результаты попыток, которые считаются неудачными при ограничении по IP.
+var failedResults = []string{models.LoginResultWrongPassword, models.LoginResultWrongCode}
+
+// IPRetryAfter возвращает, через сколько с адреса ip снова можно пытаться войти,
+// если за последние IPWindow с него было не меньше IPMaxFailures неудачных попыток (иначе 0).
+func IPRetryAfter(tx *gorm.DB, policy LoginPolicy, ip string, now time.Time) (time.Duration, error) {
+	since := now.Add(-policy.IPWindow)
+	query := tx.Model(&models.LoginAttempt{}).Where("ip = ? AND result IN ? AND created_at > ?", ip, failedResults, since)
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count < int64(policy.IPMaxFailures) {
+		return 0, nil
+	}
+
+	// Ограничение снимется, когда из окна выйдет столько попыток, чтобы их осталось меньше порога
+	var attempt models.LoginAttempt
+	if err := query.Order("created_at ASC").Offset(int(count) - policy.IPMaxFailures).First(&attempt).Error; err != nil {
+		return 0, err
+	}
+	return attempt.CreatedAt.Add(policy.IPWindow).Sub(now), nil
+}
+
+// RecordLoginAttempt добавляет запись в журнал попыток входа.
+func RecordLoginAttempt(tx *gorm.DB, userID *uint, identifier, ip, result string) error {
+	return tx.Create(&models.LoginAttempt{
+		UserID:     userID,
+		Identifier: identifier,
+		IP:         ip,
+		Result:     result,
+	}).Error
+}
+
+// RegisterLoginFailure учитывает неудачную попытку входа пользователя и при достижении порога policy
+// блокирует учетную запись. Обновляет FailedLogins и LockedUntil в user.
+func RegisterLoginFailure(tx *gorm.DB, policy LoginPolicy, user *models.User, identifier, ip, result string, now time.Time) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		// Счетчик увеличивается в базе, чтобы одновременные попытки не терялись
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+			UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Select("failed_logins").Where("id = ?", user.ID).
+			Scan(&user.FailedLogins).Error; err != nil {
+			return err
+		}
+		if lockout := policy.lockoutDuration(user.FailedLogins); lockout > 0 {
+			lockedUntil := now.Add(lockout)
+			if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+				UpdateColumn("locked_until", lockedUntil).Error; err != nil {
+				return err
+			}
+			user.LockedUntil = &lockedUntil
+		}
+		return RecordLoginAttempt(tx, &user.ID, identifier, ip, result)
+	})
+}
+
+// RegisterLoginSuccess сбрасывает счетчик неудачных попыток и записывает успешный вход в журнал.
+func RegisterLoginSuccess(tx *gorm.DB, user *models.User, identifier, ip string) error {
+	if err := Unlock(tx, user.ID); err != nil {
+		return err
+	}
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	return RecordLoginAttempt(tx, &user.ID, identifier, ip, models.LoginResultSuccess)
+}
+
+// Unlock снимает блокировку входа и обнуляет счетчик неудачных попыток.
+func Unlock(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
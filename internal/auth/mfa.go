@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/heebit/notes-api/internal/totp"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount — сколько кодов восстановления выдается за раз.
+const recoveryCodeCount = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// normalizeRecoveryCode убирает дефисы, пробелы и регистр, чтобы код можно было ввести в любом виде.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// GenerateRecoveryCodes заменяет коды восстановления пользователя новыми и возвращает их в открытом виде.
+func GenerateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for i := 0; i < recoveryCodeCount; i++ {
+			buf := make([]byte, 7)
+			if _, err := rand.Read(buf); err != nil {
+				return err
+			}
+			raw := strings.ToLower(recoveryEncoding.EncodeToString(buf))[:10]
+			codes = append(codes, raw[:5]+"-"+raw[5:])
+			if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(raw)}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return codes, err
+}
+
+// VerifySecondFactor проверяет код из приложения-аутентификатора или код восстановления.
+// Принятый код сразу становится недействительным: для TOTP запоминается шаг времени,
+// код восстановления помечается использованным.
+func VerifySecondFactor(tx *gorm.DB, user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == "" {
+		return false, nil
+	}
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		user.TOTPLastStep = step
+		return result.RowsAffected > 0, nil
+	}
+
+	if user.TOTPEnabledAt == nil {
+		return false, nil
+	}
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
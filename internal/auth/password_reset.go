@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidResetToken — токен сброса пароля не найден, истек или уже использован.
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+
+// CreatePasswordReset выпускает токен сброса пароля со сроком ttl (PASSWORD_RESET_TTL). Ранее выданные
+// неиспользованные токены пользователя аннулируются, так что действует только ссылка из последнего письма.
+func CreatePasswordReset(tx *gorm.DB, userID uint, ttl time.Duration) (string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	return token, err
+}
+
+// ConsumePasswordReset помечает токен использованным и возвращает ID пользователя.
+// Вызывается в той же транзакции, что и смена пароля.
+func ConsumePasswordReset(tx *gorm.DB, token string) (uint, error) {
+	var reset models.PasswordResetToken
+	if err := tx.Where("token_hash = ?", utils.HashToken(token)).First(&reset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrInvalidResetToken
+		}
+		return 0, err
+	}
+	if reset.UsedAt != nil || !reset.ExpiresAt.After(time.Now()) {
+		return 0, ErrInvalidResetToken
+	}
+
+	result := tx.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", reset.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInvalidResetToken
+	}
+	return reset.UserID, nil
+}
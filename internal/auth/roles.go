@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// EnsureAdmins назначает роль администратора пользователям с перечисленными email.
+// Используется при запуске, чтобы в системе появился первый администратор.
+func EnsureAdmins(tx *gorm.DB, emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	return tx.Model(&models.User{}).
+		Where("email IN ? AND role <> ?", emails, models.RoleAdmin).
+		Update("role", models.RoleAdmin).Error
+}
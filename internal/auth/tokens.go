@@ -0,0 +1,182 @@
+// Package auth хранит состояние сессий: семейства refresh-токенов и список отозванных access-токенов.
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRefreshToken — токен не найден, истек или отозван.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused — предъявлен уже использованный токен; семейство отозвано.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// IssueTokens выпускает пару токенов для нового входа, открывая новое семейство refresh-токенов.
+// Сроки действия токенов берутся из cfg.
+func IssueTokens(tx *gorm.DB, cfg config.JWTConfig, userID uint) (models.TokenPair, error) {
+	familyID, err := utils.RandomToken(16)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return issue(tx, cfg, userID, familyID)
+}
+
+func issue(tx *gorm.DB, cfg config.JWTConfig, userID uint, familyID string) (models.TokenPair, error) {
+	refresh, err := utils.RandomToken(32)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if err := tx.Create(&models.RefreshToken{
+		UserID:    userID,
+		TokenHash: utils.HashToken(refresh),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(cfg.RefreshTTL),
+	}).Error; err != nil {
+		return models.TokenPair{}, err
+	}
+
+	access, claims, err := utils.GenerateAccessToken(cfg, userID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return models.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(claims.ExpiresAt).Round(time.Second).Seconds()),
+		Token:        access,
+	}, nil
+}
+
+// Rotate обменивает refresh-токен на новую пару. Использованный токен больше не принимается;
+// повторное его предъявление означает утечку, поэтому отзывается всё семейство.
+func Rotate(tx *gorm.DB, cfg config.JWTConfig, refreshToken string) (models.TokenPair, error) {
+	var pair models.TokenPair
+	var reused bool
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if token.RevokedAt != nil || !token.ExpiresAt.After(time.Now()) {
+			return ErrInvalidRefreshToken
+		}
+		if token.UsedAt != nil {
+			reused = true
+			return RevokeFamily(tx, token.FamilyID)
+		}
+
+		// Условное обновление защищает от одновременной ротации одного токена
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return RevokeFamily(tx, token.FamilyID)
+		}
+
+		var err error
+		pair, err = issue(tx, cfg, token.UserID, token.FamilyID)
+		return err
+	})
+	if err == nil && reused {
+		return pair, ErrRefreshTokenReused
+	}
+	return pair, err
+}
+
+// RevokeFamily отзывает все refresh-токены семейства.
+func RevokeFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserToken отзывает семейство переданного refresh-токена, если он принадлежит пользователю.
+func RevokeUserToken(tx *gorm.DB, userID uint, refreshToken string) error {
+	var token models.RefreshToken
+	err := tx.Where("token_hash = ? AND user_id = ?", utils.HashToken(refreshToken), userID).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	return RevokeFamily(tx, token.FamilyID)
+}
+
+// RevokeAllForUser отзывает все refresh-токены пользователя, завершая все его сессии.
+func RevokeAllForUser(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAccessToken добавляет jti access-токена в denylist до истечения его срока.
+func RevokeAccessToken(tx *gorm.DB, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	return tx.Where(models.RevokedToken{JTI: jti}).
+		Attrs(models.RevokedToken{ExpiresAt: expiresAt}).
+		FirstOrCreate(&models.RevokedToken{}).Error
+}
+
+// IsAccessTokenRevoked сообщает, находится ли jti в denylist.
+func IsAccessTokenRevoked(tx *gorm.DB, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	var count int64
+	err := tx.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// PurgeExpired удаляет истекшие refresh-токены, токены сброса пароля и записи denylist:
+// такие токены отклоняются и без них. Заодно удаляются записи журнала входов старше attemptsRetention.
+func PurgeExpired(tx *gorm.DB, now time.Time, attemptsRetention time.Duration) error {
+	if err := tx.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("expires_at < ?", now).Delete(&models.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("created_at < ?", now.Add(-attemptsRetention)).Delete(&models.LoginAttempt{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
+
+// RunCleanup раз в interval удаляет истекшие токены и старые записи журнала входов
+// (LOGIN_ATTEMPTS_RETENTION). Работает до отмены ctx.
+func RunCleanup(ctx context.Context, tx *gorm.DB, interval, attemptsRetention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := PurgeExpired(tx, time.Now(), attemptsRetention); err != nil {
+			slog.Error("Ошибка очистки истекших токенов", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/models"
+)
+
+// GetPersonalAccessTokens godoc
+// @Summary Персональные токены доступа
+// @Description Возвращает токены текущего пользователя без самих значений токенов
+// @Tags tokens
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.PersonalAccessToken
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/tokens [get]
+func (h *AuthHandler) GetPersonalAccessTokens(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	tokens := []models.PersonalAccessToken{}
+	if err := withRequest(c, h.db).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&tokens).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении токенов"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreatePersonalAccessToken godoc
+// @Summary Создать персональный токен доступа
+// @Description Создает токен для скриптов и интеграций. Токен передается в заголовке Authorization: Bearer pat_... и показывается только в этом ответе. Доступные области: notes:read, notes:write.
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param token body models.PersonalAccessTokenInput true "Название, области и срок действия"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.CreatedPersonalAccessToken
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/tokens [post]
+func (h *AuthHandler) CreatePersonalAccessToken(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	var input models.PersonalAccessTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Срок действия токена должен быть в будущем"})
+		return
+	}
+
+	token, pat, err := auth.CreatePersonalAccessToken(withRequest(c, h.db), userID, input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать токен"})
+		return
+	}
+	c.JSON(http.StatusCreated, models.CreatedPersonalAccessToken{PersonalAccessToken: pat, Token: token})
+}
+
+// RevokePersonalAccessToken godoc
+// @Summary Отозвать персональный токен доступа
+// @Tags tokens
+// @Produce json
+// @Param id path int true "ID токена"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /auth/tokens/{id} [delete]
+func (h *AuthHandler) RevokePersonalAccessToken(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID токена"})
+		return
+	}
+	result := withRequest(c, h.db).Where("id = ? AND user_id = ?", uint(id), userID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось отозвать токен"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Токен не найден"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Токен отозван"})
+}
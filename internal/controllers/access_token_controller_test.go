@@ -0,0 +1,114 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalAccessTokens(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	authHandler := newAuthHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	tokens := r.Group("/auth/tokens").Use(middleware.AuthMiddleware(testDB, testConfig().JWT), middleware.RequireSession())
+	tokens.GET("/", authHandler.GetPersonalAccessTokens)
+	tokens.POST("/", authHandler.CreatePersonalAccessToken)
+	tokens.DELETE("/:id", authHandler.RevokePersonalAccessToken)
+	notes := r.Group("/notes").Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	notes.GET("/", middleware.RequireScope(models.ScopeNotesRead), noteHandler.GetNotes)
+	notes.POST("/", middleware.RequireScope(models.ScopeNotesWrite), noteHandler.CreateNote)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_pat", "pat@example.com", "password123")
+
+	createToken := func(t *testing.T, input models.PersonalAccessTokenInput) models.CreatedPersonalAccessToken {
+		w := doRequest(r, http.MethodPost, "/auth/tokens/", token, input)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var created models.CreatedPersonalAccessToken
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created
+	}
+	newNote := models.NoteInput{Title: "Из скрипта", Content: "Содержимое"}
+
+	t.Run("CreatePersonalAccessToken - Validation", func(t *testing.T) {
+		t.Log("Запуск: CreatePersonalAccessToken - Проверка названия, областей и срока")
+		w := doRequest(r, http.MethodPost, "/auth/tokens/", token, models.PersonalAccessTokenInput{Name: "script"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doRequest(r, http.MethodPost, "/auth/tokens/", token, models.PersonalAccessTokenInput{Name: "script", Scopes: []string{"admin"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		past := time.Now().Add(-time.Hour)
+		w = doRequest(r, http.MethodPost, "/auth/tokens/", token, models.PersonalAccessTokenInput{Name: "script", Scopes: []string{models.ScopeNotesRead}, ExpiresAt: &past})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Read-only token - Scopes are enforced", func(t *testing.T) {
+		t.Log("Запуск: RequireScope - Токен только для чтения")
+		created := createToken(t, models.PersonalAccessTokenInput{Name: "backup", Scopes: []string{models.ScopeNotesRead}})
+		assert.True(t, strings.HasPrefix(created.Token, models.PersonalAccessTokenPrefix))
+		assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+		assert.Nil(t, created.LastUsedAt)
+
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/notes/", created.Token, nil).Code)
+		w := doRequest(r, http.MethodPost, "/notes/", created.Token, newNote)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), models.ScopeNotesWrite)
+
+		var stored models.PersonalAccessToken
+		assert.NoError(t, testDB.First(&stored, created.ID).Error)
+		assert.NotNil(t, stored.LastUsedAt, "Время последнего использования обновляется")
+
+		w = doRequest(r, http.MethodGet, "/auth/tokens/", created.Token, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code, "Токен не может управлять токенами")
+	})
+
+	t.Run("Write token - Creates notes", func(t *testing.T) {
+		t.Log("Запуск: RequireScope - Токен с правом записи")
+		created := createToken(t, models.PersonalAccessTokenInput{Name: "import", Scopes: []string{models.ScopeNotesRead, models.ScopeNotesWrite}})
+		w := doRequest(r, http.MethodPost, "/notes/", created.Token, newNote)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var note models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+		assert.Equal(t, userID, note.UserID)
+	})
+
+	t.Run("GetPersonalAccessTokens - Token is not shown again", func(t *testing.T) {
+		t.Log("Запуск: GetPersonalAccessTokens - Список токенов")
+		created := createToken(t, models.PersonalAccessTokenInput{Name: "listed", Scopes: []string{models.ScopeNotesRead}})
+		w := doRequest(r, http.MethodGet, "/auth/tokens/", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "listed")
+		assert.NotContains(t, w.Body.String(), created.Token)
+		assert.NotContains(t, w.Body.String(), "token_hash")
+	})
+
+	t.Run("Expired and revoked tokens", func(t *testing.T) {
+		t.Log("Запуск: AuthMiddleware - Истекший и отозванный токены")
+		soon := time.Now().Add(time.Hour)
+		expiring := createToken(t, models.PersonalAccessTokenInput{Name: "expiring", Scopes: []string{models.ScopeNotesRead}, ExpiresAt: &soon})
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/notes/", expiring.Token, nil).Code)
+		testDB.Model(&models.PersonalAccessToken{}).Where("id = ?", expiring.ID).Update("expires_at", time.Now().Add(-time.Minute))
+		assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, "/notes/", expiring.Token, nil).Code)
+
+		revoked := createToken(t, models.PersonalAccessTokenInput{Name: "revoked", Scopes: []string{models.ScopeNotesRead}})
+		tokenPath := "/auth/tokens/" + strconv.FormatUint(uint64(revoked.ID), 10)
+		token2, _ := registerAndLoginUser(t, testDB, "anotheruser_pat", "another_pat@example.com", "password123")
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodDelete, tokenPath, token2, nil).Code, "Чужой токен отозвать нельзя")
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, tokenPath, token, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, "/notes/", revoked.Token, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, "/notes/", "pat_unknown", nil).Code)
+	})
+}
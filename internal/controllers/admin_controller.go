@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AdminHandler обрабатывает запросы административного API.
+type AdminHandler struct {
+	db   *gorm.DB
+	mail mailer.Mailer
+	cfg  *config.Config
+}
+
+// NewAdminHandler создает AdminHandler, работающий с подключением tx и отправляющий письма через mail
+// со ссылками по настройкам cfg.
+func NewAdminHandler(tx *gorm.DB, mail mailer.Mailer, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{db: tx, mail: mail, cfg: cfg}
+}
+
+// adminUserSortColumns — поля, по которым можно сортировать список пользователей.
+var adminUserSortColumns = map[string]string{
+	"created_at": "created_at",
+	"username":   "username",
+	"email":      "email",
+}
+
+// adminUserCursorValue возвращает значение поля сортировки пользователя для курсора.
+func adminUserCursorValue(user models.User, sort string) string {
+	switch sort {
+	case "created_at":
+		return user.CreatedAt.Format(time.RFC3339Nano)
+	case "email":
+		return user.Email
+	default:
+		return user.Username
+	}
+}
+
+// toAdminUsers дополняет пользователей количеством их заметок (без заметок в корзине).
+func toAdminUsers(tx *gorm.DB, users []models.User) ([]models.AdminUser, error) {
+	result := make([]models.AdminUser, 0, len(users))
+	if len(users) == 0 {
+		return result, nil
+	}
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+
+	var counts []struct {
+		UserID uint
+		Count  int64
+	}
+	if err := tx.Model(&models.Note{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ?", ids).
+		Group("user_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	noteCounts := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		noteCounts[row.UserID] = row.Count
+	}
+
+	for _, user := range users {
+		result = append(result, models.AdminUser{
+			ID:            user.ID,
+			Username:      user.Username,
+			Email:         user.Email,
+			Role:          user.Role,
+			EmailVerified: user.EmailVerifiedAt != nil,
+			MFAEnabled:    user.TOTPEnabledAt != nil,
+			DisabledAt:    user.DisabledAt,
+			LockedUntil:   user.LockedUntil,
+			NoteCount:     noteCounts[user.ID],
+			CreatedAt:     user.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// respondAdminUser отправляет пользователя в формате административного API.
+func (h *AdminHandler) respondAdminUser(c *gin.Context, user models.User) {
+	users, err := toAdminUsers(withRequest(c, h.db), []models.User{user})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при подсчете заметок пользователя"})
+		return
+	}
+	c.JSON(http.StatusOK, users[0])
+}
+
+// findAdminTarget загружает пользователя по ID из параметра пути. При ошибке ответ уже отправлен.
+func (h *AdminHandler) findAdminTarget(c *gin.Context) (models.User, bool) {
+	var user models.User
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID пользователя"})
+		return user, false
+	}
+	if err := withRequest(c, h.db).First(&user, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Пользователь не найден"})
+			return user, false
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске пользователя"})
+		return user, false
+	}
+	return user, true
+}
+
+// findOtherAdminTarget — findAdminTarget, запрещающий администратору применять операцию к самому себе,
+// чтобы он не мог случайно лишить себя доступа.
+func (h *AdminHandler) findOtherAdminTarget(c *gin.Context) (models.User, bool) {
+	adminID, ok := getUserIdFromContext(c)
+	if !ok {
+		return models.User{}, false
+	}
+	user, ok := h.findAdminTarget(c)
+	if !ok {
+		return user, false
+	}
+	if user.ID == adminID {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Операция недоступна для собственной учетной записи"})
+		return user, false
+	}
+	return user, true
+}
+
+// AdminGetUsers godoc
+// @Summary Список пользователей
+// @Description Возвращает страницу пользователей с количеством заметок. Доступно только администраторам.
+// @Tags admin
+// @Produce json
+// @Param q query string false "Поиск по имени пользователя или email"
+// @Param role query string false "Роль" Enums(user, admin)
+// @Param disabled query bool false "Только заблокированные (true) или только активные (false)"
+// @Param limit query int false "Размер страницы (по умолчанию 20, не более 100)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Поле сортировки" Enums(created_at, username, email) default(created_at)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(desc)
+// @Security ApiKeyAuth
+// @Success 200 {array} models.AdminUser
+// @Header 200 {integer} X-Total-Count "Общее количество пользователей, подходящих под фильтры"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /admin/users [get]
+func (h *AdminHandler) AdminGetUsers(c *gin.Context) {
+	params, err := parseListParams(c, adminUserSortColumns, "created_at")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query := withRequest(c, h.db).Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(users.username) LIKE ? OR LOWER(users.email) LIKE ?", pattern, pattern)
+	}
+	if role := c.Query("role"); role != "" {
+		if role != models.RoleUser && role != models.RoleAdmin {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Недопустимая роль: " + role})
+			return
+		}
+		query = query.Where("users.role = ?", role)
+	}
+	if disabledStr := c.Query("disabled"); disabledStr != "" {
+		disabled, err := strconv.ParseBool(disabledStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Параметр disabled должен быть true или false"})
+			return
+		}
+		if disabled {
+			query = query.Where("users.disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("users.disabled_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении пользователей"})
+		return
+	}
+
+	var users []models.User
+	if err := paginate(query, params, adminUserSortColumns, "users").Find(&users).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении пользователей"})
+		return
+	}
+
+	var nextCursor string
+	if len(users) > params.Limit {
+		users = users[:params.Limit]
+		last := users[len(users)-1]
+		nextCursor = encodeCursor(pageCursor{
+			Sort:  params.Sort,
+			Order: params.Order,
+			Value: adminUserCursorValue(last, params.Sort),
+			ID:    last.ID,
+		})
+	}
+
+	result, err := toAdminUsers(withRequest(c, h.db), users)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при подсчете заметок пользователей"})
+		return
+	}
+	setPaginationHeaders(c, total, nextCursor)
+	c.JSON(http.StatusOK, result)
+}
+
+// AdminGetUser godoc
+// @Summary Пользователь
+// @Description Возвращает пользователя со статусом учетной записи и количеством заметок. Доступно только администраторам.
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminUser
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) AdminGetUser(c *gin.Context) {
+	user, ok := h.findAdminTarget(c)
+	if !ok {
+		return
+	}
+	h.respondAdminUser(c, user)
+}
+
+// AdminUpdateUserRole godoc
+// @Summary Изменить роль пользователя
+// @Description Назначает пользователю роль user или admin. Собственную роль изменить нельзя.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param role body models.UpdateRoleInput true "Новая роль"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminUser
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) AdminUpdateUserRole(c *gin.Context) {
+	var input models.UpdateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	user, ok := h.findOtherAdminTarget(c)
+	if !ok {
+		return
+	}
+	if err := withRequest(c, h.db).Model(&user).Update("role", input.Role).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось изменить роль"})
+		return
+	}
+	h.respondAdminUser(c, user)
+}
+
+// AdminDisableUser godoc
+// @Summary Заблокировать пользователя
+// @Description Блокирует учетную запись: вход и запросы с её токенами отклоняются, все сессии завершаются.
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminUser
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/disable [post]
+func (h *AdminHandler) AdminDisableUser(c *gin.Context) {
+	user, ok := h.findOtherAdminTarget(c)
+	if !ok {
+		return
+	}
+	if user.DisabledAt == nil {
+		err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("disabled_at", time.Now()).Error; err != nil {
+				return err
+			}
+			return auth.RevokeAllForUser(tx, user.ID)
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось заблокировать пользователя"})
+			return
+		}
+	}
+	h.respondAdminUser(c, user)
+}
+
+// AdminEnableUser godoc
+// @Summary Разблокировать пользователя
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminUser
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/enable [post]
+func (h *AdminHandler) AdminEnableUser(c *gin.Context) {
+	user, ok := h.findAdminTarget(c)
+	if !ok {
+		return
+	}
+	if err := withRequest(c, h.db).Model(&user).Update("disabled_at", nil).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось разблокировать пользователя"})
+		return
+	}
+	h.respondAdminUser(c, user)
+}
+
+// AdminResetUserPassword godoc
+// @Summary Принудительный сброс пароля
+// @Description Делает текущий пароль пользователя недействительным, завершает все его сессии и отправляет на email ссылку для установки нового пароля.
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users/{id}/password-reset [post]
+func (h *AdminHandler) AdminResetUserPassword(c *gin.Context) {
+	user, ok := h.findAdminTarget(c)
+	if !ok {
+		return
+	}
+
+	// Случайный пароль никому не известен: войти можно только после сброса по ссылке из письма
+	random, err := utils.RandomToken(32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось сбросить пароль"})
+		return
+	}
+	hashedPassword, err := utils.HashPassword(random, bcrypt.DefaultCost)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось захешировать пароль"})
+		return
+	}
+	err = withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return auth.RevokeAllForUser(tx, user.ID)
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось сбросить пароль"})
+		return
+	}
+
+	if err := sendPasswordResetEmail(c.Request.Context(), withRequest(c, h.db), h.mail, h.cfg, user); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Не удалось отправить письмо для сброса пароля", "recipient_id", user.ID, "error", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Пароль сброшен, но письмо со ссылкой отправить не удалось"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Пароль сброшен, пользователю отправлена ссылка для установки нового пароля"})
+}
@@ -0,0 +1,134 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	authHandler := newAuthHandler(testDB)
+	adminHandler := newAdminHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.POST("/auth/login", authHandler.Login)
+	r.POST("/auth/refresh", authHandler.Refresh)
+	r.GET("/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), noteHandler.GetNotes)
+	admin := r.Group("/admin").Use(middleware.AuthMiddleware(testDB, testConfig().JWT), middleware.RequireRole(models.RoleAdmin))
+	admin.GET("/users", adminHandler.AdminGetUsers)
+	admin.GET("/users/:id", adminHandler.AdminGetUser)
+	admin.PUT("/users/:id/role", adminHandler.AdminUpdateUserRole)
+	admin.POST("/users/:id/disable", adminHandler.AdminDisableUser)
+	admin.POST("/users/:id/enable", adminHandler.AdminEnableUser)
+	admin.POST("/users/:id/password-reset", adminHandler.AdminResetUserPassword)
+
+	adminToken, adminID := registerAndLoginUser(t, testDB, "testuser_admin", "admin@example.com", "password123")
+	testDB.Model(&models.User{}).Where("id = ?", adminID).Update("role", models.RoleAdmin)
+	token, userID := registerAndLoginUser(t, testDB, "testuser_regular", "regular@example.com", "password123")
+	userPath := "/admin/users/" + strconv.FormatUint(uint64(userID), 10)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, testDB.Create(&models.Note{Title: "Заметка", Content: "Текст", UserID: userID}).Error)
+	}
+
+	t.Run("RequireRole - Regular user is forbidden", func(t *testing.T) {
+		t.Log("Запуск: RequireRole - Обычный пользователь без доступа к admin API")
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodGet, "/admin/users", token, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, "/admin/users", "", nil).Code)
+	})
+
+	t.Run("AdminGetUsers - Search and note counts", func(t *testing.T) {
+		t.Log("Запуск: AdminGetUsers - Поиск пользователей")
+		w := doRequest(r, http.MethodGet, "/admin/users?q=REGULAR", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+		var users []models.AdminUser
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+		if assert.Len(t, users, 1) {
+			assert.Equal(t, userID, users[0].ID)
+			assert.Equal(t, models.RoleUser, users[0].Role)
+			assert.Equal(t, int64(3), users[0].NoteCount)
+		}
+		assert.NotContains(t, w.Body.String(), "password")
+
+		w = doRequest(r, http.MethodGet, "/admin/users?role=admin", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+		if assert.Len(t, users, 1) {
+			assert.Equal(t, adminID, users[0].ID)
+		}
+
+		w = doRequest(r, http.MethodGet, "/admin/users?limit=1&sort=username&order=asc", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get("X-Next-Cursor"))
+
+		assert.Equal(t, http.StatusBadRequest, doRequest(r, http.MethodGet, "/admin/users?role=owner", adminToken, nil).Code)
+	})
+
+	t.Run("AdminDisableUser - Blocks tokens and login", func(t *testing.T) {
+		t.Log("Запуск: AdminDisableUser - Блокировка учетной записи")
+		w := doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_regular", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var session models.TokenPair
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+
+		w = doRequest(r, http.MethodPost, "/admin/users/"+strconv.FormatUint(uint64(adminID), 10)+"/disable", adminToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Администратор не может заблокировать себя")
+
+		w = doRequest(r, http.MethodPost, userPath+"/disable", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var disabled models.AdminUser
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &disabled))
+		assert.NotNil(t, disabled.DisabledAt)
+
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodGet, "/notes", token, nil).Code)
+		w = doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_regular", Password: "password123"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doRequest(r, http.MethodPost, "/auth/refresh", "", models.RefreshInput{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Сессии заблокированного пользователя завершаются")
+
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodPost, userPath+"/enable", adminToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/notes", token, nil).Code)
+	})
+
+	t.Run("AdminUpdateUserRole - Promote and demote", func(t *testing.T) {
+		t.Log("Запуск: AdminUpdateUserRole - Назначение роли")
+		w := doRequest(r, http.MethodPut, userPath+"/role", adminToken, models.UpdateRoleInput{Role: "owner"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doRequest(r, http.MethodPut, userPath+"/role", adminToken, models.UpdateRoleInput{Role: models.RoleAdmin})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/admin/users", token, nil).Code)
+
+		w = doRequest(r, http.MethodPut, userPath+"/role", adminToken, models.UpdateRoleInput{Role: models.RoleUser})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodGet, "/admin/users", token, nil).Code)
+
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodGet, "/admin/users/999999", adminToken, nil).Code)
+	})
+
+	t.Run("AdminResetUserPassword - Old password stops working", func(t *testing.T) {
+		t.Log("Запуск: AdminResetUserPassword - Принудительный сброс пароля")
+		w := doRequest(r, http.MethodPost, userPath+"/password-reset", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_regular", Password: "password123"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		var count int64
+		testDB.Model(&models.OutboxEmail{}).Where("\"to\" = ?", "regular@example.com").Count(&count)
+		assert.Equal(t, int64(1), count, "Пользователю отправлена ссылка для сброса пароля")
+	})
+}
@@ -1,27 +1,58 @@
 package controllers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
-	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/mailer"
 	"github.com/heebit/notes-api/models"
 	"github.com/heebit/notes-api/utils"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// dummyPasswordHash — хеш bcrypt со стоимостью регистрации. Вход по несуществующему имени
+// сверяет пароль с ним, чтобы по времени ответа нельзя было узнать, есть ли такая учетная запись.
+const dummyPasswordHash = "$2a$14$ACaZTMcnLvcRSJW/Wyf4J..4JEfhYgyGVV/1FJK90QBeKNhDfyY46"
+
+// AuthHandler обрабатывает регистрацию, вход, двухфакторную аутентификацию, персональные токены
+// и восстановление доступа.
+type AuthHandler struct {
+	db     *gorm.DB
+	mail   mailer.Mailer
+	cfg    *config.Config
+	policy auth.LoginPolicy
+
+	// background — отправки писем, продолжающиеся после ответа клиенту
+	background sync.WaitGroup
+}
+
+// NewAuthHandler создает AuthHandler, работающий с подключением tx и отправляющий письма через mail.
+// Сроки токенов, ссылки в письмах и защита входа берутся из cfg.
+func NewAuthHandler(tx *gorm.DB, mail mailer.Mailer, cfg *config.Config) *AuthHandler {
+	return &AuthHandler{db: tx, mail: mail, cfg: cfg, policy: auth.NewLoginPolicy(cfg.Login)}
+}
+
+// Wait ждет завершения фоновых отправок писем. Вызывается при остановке сервера до закрытия базы данных.
+func (h *AuthHandler) Wait() {
+	h.background.Wait()
+}
+
 // Register godoc
 // @Summary Регистрация пользователя
-// @Description Создание нового пользователя
+// @Description Создание нового пользователя. На email отправляется ссылка подтверждения.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param input body models.User true "Данные пользователя"
 // @Success 201 {object} models.MessageResponse
 // @Failure 400 {object} models.ErrorResponse
-// @Router /register [post]
-func Register(c *gin.Context) {
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
     var input models.User
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
@@ -30,26 +61,32 @@ func Register(c *gin.Context) {
 
     var existingUser models.User
     // Сначала ищем существующего пользователя
-    if db.DB.Where("username = ? OR email = ?", input.Username, input.Email).First(&existingUser).Error == nil {
+    if withRequest(c, h.db).Where("username = ? OR email = ?", input.Username, input.Email).First(&existingUser).Error == nil {
         // Если пользователь найден (ошибки нет), значит, он уже существует
         c.JSON(http.StatusConflict, gin.H{"error": "Пользователь с таким именем или email уже существует"})
         return
     }
     // Если result.Error == gorm.ErrRecordNotFound, то пользователя нет, можно продолжать
 
-    if hashed, err := bcrypt.GenerateFromPassword([]byte(input.Password), 14); err != nil {
+    if hashed, err := utils.HashPassword(input.Password, 14); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка хеширования пароля"})
         return
     } else {
-        input.Password = string(hashed)
+        input.Password = hashed
+        // Email подтверждается только по ссылке из письма
+        input.EmailVerifiedAt = nil
+        input.VerificationSentAt = nil
         // Теперь создаем пользователя
-        if result := db.DB.Create(&input); result.Error != nil {
+        if result := withRequest(c, h.db).Create(&input); result.Error != nil {
             // Если при создании возникает ошибка (например, UNIQUE constraint, хотя мы уже проверяли)
             // Это запасной вариант, если что-то пошло не так
             c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось зарегистрировать пользователя"})
             return
         }
-        c.JSON(http.StatusCreated, gin.H{"message": "Пользователь успешно зарегистрирован"})
+        if err := sendVerificationEmail(c.Request.Context(), withRequest(c, h.db), h.mail, h.cfg, &input); err != nil {
+            slog.ErrorContext(c.Request.Context(), "Не удалось отправить письмо подтверждения", "recipient_id", input.ID, "error", err)
+        }
+        c.JSON(http.StatusCreated, gin.H{"message": "Пользователь успешно зарегистрирован. Подтвердите email по ссылке из письма"})
     }
 }
 
@@ -61,21 +98,31 @@ func Register(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param input body models.User true "Credentials"
-// @Success 200 {object} models.MessageResponse
+// @Success 200 {object} models.TokenPair "Токены; при включенной двухфакторной аутентификации — models.MFAChallenge"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
-// @Router /login [post]
-func Login(c *gin.Context) {
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
 	var input models.LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
 		return
 	}
 
+	// Слишком много неудачных попыток с одного адреса — перебор по разным учетным записям
+	ip := c.ClientIP()
+	if !h.checkIPAllowed(c, input.Identifier, ip) {
+		return
+	}
+
 	var user models.User
-	result := db.DB.Where("username = ? OR email = ?", input.Identifier, input.Identifier).First(&user)
+	result := withRequest(c, h.db).Where("username = ? OR email = ?", input.Identifier, input.Identifier).First(&user)
 	if result.Error != nil {
         if result.Error == gorm.ErrRecordNotFound {
+            utils.ComparePassword(dummyPasswordHash, input.Password)
+            h.registerLoginFailure(c.Request.Context(), nil, input.Identifier, ip, models.LoginResultWrongPassword)
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверное имя пользователя или пароль"})
             return
         }
@@ -84,21 +131,130 @@ func Login(c *gin.Context) {
         return
     }
 
+    // Пока действует блокировка после неудачных попыток, пароль не проверяется
+    if !h.checkAccountUnlocked(c, user, input.Identifier, ip) {
+        return
+    }
+
     // Проверяем пароль
-    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+    if err := utils.ComparePassword(user.Password, input.Password); err != nil {
+        h.registerLoginFailure(c.Request.Context(), &user, input.Identifier, ip, models.LoginResultWrongPassword)
         c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверное имя пользователя или пароль"})
         return
     }
 
-    // Генерируем JWT токен
-    if token, err := utils.GenerateJWT(user.ID); err != nil {
+    // Заблокированной учетной записи вход закрыт; сообщается только после проверки пароля
+    if user.DisabledAt != nil {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Учетная запись заблокирована"})
+        return
+    }
+
+    // При включенной двухфакторной аутентификации токены выдаются только после проверки кода;
+    // счетчик неудачных попыток сбрасывается тоже только после него
+    if user.TOTPEnabledAt != nil {
+        mfaToken, err := utils.GenerateMFAToken(h.cfg.JWT, h.cfg.Auth.MFATokenTTL, user.ID)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токена"})
+            return
+        }
+        c.JSON(http.StatusOK, models.MFAChallenge{
+            MFARequired: true,
+            MFAToken:    mfaToken,
+            ExpiresIn:   int(h.cfg.Auth.MFATokenTTL.Seconds()),
+        })
+        return
+    }
+
+    h.registerLoginSuccess(c.Request.Context(), &user, input.Identifier, ip)
+
+    // Выпускаем access-токен и refresh-токен нового семейства
+    if tokens, err := auth.IssueTokens(withRequest(c, h.db), h.cfg.JWT, user.ID); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токена"})
     } else {
-        c.JSON(http.StatusOK, gin.H{"token": token})
+        c.JSON(http.StatusOK, tokens)
     }
 }
+
+// Refresh godoc
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен на новую пару токенов. Каждый refresh-токен действует один раз; повторное предъявление уже использованного токена завершает все сессии, выпущенные от того же входа.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param input body models.RefreshInput true "Refresh-токен"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input models.RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+
+	tokens, err := auth.Rotate(withRequest(c, h.db), h.cfg.JWT, input.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh-токен уже был использован, все сессии этого входа завершены"})
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный refresh-токен"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обновления токена"})
+	default:
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+// Logout godoc
+// @Summary Выход из системы
+// @Description Отзывает текущий access-токен. Если передан refresh-токен, завершается и его сессия; all=true завершает все сессии пользователя.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param input body models.LogoutInput false "Refresh-токен текущей сессии"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	var input models.LogoutInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+			return
+		}
+	}
+
+	err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := auth.RevokeAccessToken(tx, c.GetString("token_jti"), c.GetTime("token_expires_at")); err != nil {
+			return err
+		}
+		if input.All {
+			return auth.RevokeAllForUser(tx, userID)
+		}
+		if input.RefreshToken != "" {
+			// Чужой или уже удаленный токен не мешает выходу: текущий access-токен все равно отзывается
+			if err := auth.RevokeUserToken(tx, userID, input.RefreshToken); err != nil && !errors.Is(err, auth.ErrInvalidRefreshToken) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка выхода из системы"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Выход выполнен"})
+}
 	
 // Register создает нового пользователя, хешируя его пароль перед сохранением в базу данных.
-// Login проверяет введенные учетные данные пользователя и, если они верны, генерирует JWT токен для авторизации.
+// Login проверяет введенные учетные данные пользователя и, если они верны, выдает короткоживущий JWT access-токен и refresh-токен.
+// Refresh ротирует refresh-токен, Logout отзывает токены текущей сессии.
 // Оба метода используют модели и функции из пакета db для взаимодействия с базой данных и utils для генерации JWT токена.
 // Эти функции должны быть защищены от SQL-инъекций и других уязвимостей, что достигается использованием ORM GORM и безопасных методов хеширования паролей.
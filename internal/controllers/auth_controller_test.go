@@ -6,25 +6,26 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
 	"github.com/heebit/notes-api/models" // Убедитесь, что импортировали models
+	"github.com/heebit/notes-api/utils"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
 
-
-
 func TestRegitster(t *testing.T) {
-	testDB := setupTestDB()
+	testDB := setupTestDB(t)
+	authHandler := newAuthHandler(testDB)
 	defer func() {
 		sqlDB, _ := testDB.DB()
 		sqlDB.Close()
 	}()
 
 	r := gin.Default()
-	r.POST("/register", controllers.Register)
+	r.POST("/register", authHandler.Register)
 
 	t.Run("Successful Registration", func(t *testing.T) {
 		newUser := models.User{
@@ -107,7 +108,8 @@ func TestRegitster(t *testing.T) {
 }
 
 func TestLogin(t *testing.T) {
-	testDB := setupTestDB()
+	testDB := setupTestDB(t)
+	authHandler := newAuthHandler(testDB)
 	defer func() {
 		sqlDB, _ := testDB.DB()
 		sqlDB.Close()
@@ -123,7 +125,7 @@ func TestLogin(t *testing.T) {
 	testDB.Create(&user)
 
 	r := gin.Default()
-	r.POST("/login", controllers.Login)
+	r.POST("/login", authHandler.Login)
 
 	t.Run("Successful Login by Username", func(t *testing.T) {
 		credentials := models.LoginInput{ // <--- Используем LoginInput
@@ -231,4 +233,98 @@ func TestLogin(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "Неверный ввод")
 	})
-}
\ No newline at end of file
+}
+func TestRefreshAndLogout(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	authHandler := newAuthHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	testDB.Create(&models.User{Username: "testuser_refresh", Email: "refresh@example.com", Password: string(hashedPassword)})
+
+	r := gin.Default()
+	r.POST("/login", authHandler.Login)
+	r.POST("/refresh", authHandler.Refresh)
+	r.POST("/logout", middleware.AuthMiddleware(testDB, testConfig().JWT), authHandler.Logout)
+	r.GET("/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), noteHandler.GetNotes)
+
+	login := func(t *testing.T) models.TokenPair {
+		w := doRequest(r, http.MethodPost, "/login", "", models.LoginInput{Identifier: "testuser_refresh", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var tokens models.TokenPair
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+		return tokens
+	}
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		return doRequest(r, http.MethodPost, "/refresh", "", models.RefreshInput{RefreshToken: refreshToken})
+	}
+
+	t.Run("Login - Returns token pair", func(t *testing.T) {
+		tokens := login(t)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		assert.Equal(t, tokens.AccessToken, tokens.Token)
+		assert.Equal(t, "Bearer", tokens.TokenType)
+		assert.Positive(t, tokens.ExpiresIn)
+
+		var stored models.RefreshToken
+		assert.NoError(t, testDB.First(&stored).Error)
+		assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash, "В базе хранится только хеш токена")
+	})
+
+	t.Run("Refresh - Rotation and reuse detection", func(t *testing.T) {
+		tokens := login(t)
+
+		w := refresh(tokens.RefreshToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var rotated models.TokenPair
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+		assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/notes", rotated.AccessToken, nil).Code)
+
+		// Повторное использование старого токена отзывает всё семейство, включая новый токен
+		w = refresh(tokens.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "все сессии этого входа завершены")
+		assert.Equal(t, http.StatusUnauthorized, refresh(rotated.RefreshToken).Code)
+
+		other := login(t)
+		assert.Equal(t, http.StatusOK, refresh(other.RefreshToken).Code, "Другие входы пользователя не затрагиваются")
+
+		assert.Equal(t, http.StatusUnauthorized, refresh("unknown").Code)
+		assert.Equal(t, http.StatusBadRequest, doRequest(r, http.MethodPost, "/refresh", "", nil).Code)
+	})
+
+	t.Run("Refresh - Expired token", func(t *testing.T) {
+		tokens := login(t)
+		testDB.Model(&models.RefreshToken{}).Where("token_hash = ?", utils.HashToken(tokens.RefreshToken)).
+			Update("expires_at", time.Now().Add(-time.Minute))
+		assert.Equal(t, http.StatusUnauthorized, refresh(tokens.RefreshToken).Code)
+	})
+
+	t.Run("Logout - Revokes access and refresh tokens", func(t *testing.T) {
+		tokens := login(t)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/notes", tokens.AccessToken, nil).Code)
+
+		w := doRequest(r, http.MethodPost, "/logout", tokens.AccessToken, models.LogoutInput{RefreshToken: tokens.RefreshToken})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doRequest(r, http.MethodGet, "/notes", tokens.AccessToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Токен авторизации отозван")
+		assert.Equal(t, http.StatusUnauthorized, refresh(tokens.RefreshToken).Code)
+	})
+
+	t.Run("Logout - All sessions", func(t *testing.T) {
+		first := login(t)
+		second := login(t)
+		w := doRequest(r, http.MethodPost, "/logout", second.AccessToken, models.LogoutInput{All: true})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, refresh(first.RefreshToken).Code)
+		assert.Equal(t, http.StatusUnauthorized, refresh(second.RefreshToken).Code)
+	})
+}
@@ -0,0 +1,12 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// withRequest привязывает запросы к базе к контексту HTTP-запроса: они прерываются, если клиент
+// ушел, и попадают в трассировку и журнал этого запроса.
+func withRequest(c *gin.Context, tx *gorm.DB) *gorm.DB {
+	return tx.WithContext(c.Request.Context())
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/migrate"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// readinessTimeout ограничивает время проверок /readyz, чтобы зависшая база не держала запрос.
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler отвечает на проверки живости и готовности сервера.
+type HealthHandler struct {
+	db         *gorm.DB
+	migrations *migrate.Migrator
+	draining   atomic.Bool
+}
+
+// NewHealthHandler создает HealthHandler, проверяющий подключение tx и применение миграций migrator.
+func NewHealthHandler(tx *gorm.DB, migrator *migrate.Migrator) *HealthHandler {
+	return &HealthHandler{db: tx, migrations: migrator}
+}
+
+// Drain помечает сервер останавливающимся: /readyz начинает отвечать 503, чтобы балансировщик
+// перестал направлять сюда новые запросы, пока принятые дорабатывают.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Healthz godoc
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс запущен и обрабатывает запросы. Базу данных не проверяет.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthResponse{Status: "ok"})
+}
+
+// Readyz godoc
+// @Summary Проверка готовности
+// @Description Проверяет подключение к базе данных и применение миграций. Отвечает 503, если база недоступна,
+// @Description есть непримененные миграции или сервер останавливается.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.ReadinessResponse
+// @Failure 503 {object} models.ReadinessResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	response := models.ReadinessResponse{Status: "ok", Database: "ok"}
+	// /readyz доступен без аутентификации, поэтому текст ошибок драйвера остается только в журнале
+	if err := h.ping(ctx); err != nil {
+		slog.ErrorContext(ctx, "База данных недоступна", "error", err)
+		response.Status, response.Database = "unavailable", "unavailable"
+	}
+	response.Migrations = h.migrationStatus(ctx)
+	if response.Migrations.Status != "ok" {
+		response.Status = "unavailable"
+	}
+	if h.draining.Load() {
+		response.Status = "draining"
+	}
+
+	code := http.StatusOK
+	if response.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, response)
+}
+
+func (h *HealthHandler) ping(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// migrationStatus сравнивает примененные миграции со встроенными в приложение.
+func (h *HealthHandler) migrationStatus(ctx context.Context) models.MigrationsStatus {
+	statuses, err := h.migrations.Status(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Не удалось проверить состояние миграций", "error", err)
+		return models.MigrationsStatus{Status: "unknown"}
+	}
+	result := models.MigrationsStatus{Status: "ok"}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			result.Pending++
+		} else {
+			result.Version = max(result.Version, status.Version)
+		}
+	}
+	if result.Pending > 0 {
+		result.Status = "pending"
+	}
+	return result
+}
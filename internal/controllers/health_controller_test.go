@@ -0,0 +1,115 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/internal/migrate"
+	"github.com/heebit/notes-api/migrations"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestHealthController(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	newHealthHandler := func(tx *gorm.DB) *controllers.HealthHandler {
+		sqlDB, _ := tx.DB()
+		migrator, err := migrate.New(sqlDB, "sqlite", migrations.FS)
+		if err != nil {
+			t.Fatalf("не удалось загрузить миграции: %v", err)
+		}
+		return controllers.NewHealthHandler(tx, migrator)
+	}
+	// emptyDB — отдельная база без миграций
+	emptyDB := func() *gorm.DB {
+		gormDB, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "empty.db")), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("не удалось открыть базу: %v", err)
+		}
+		return gormDB
+	}
+	// probe выполняет запрос проверки состояния к обработчику h
+	probe := func(h *controllers.HealthHandler, url string) *httptest.ResponseRecorder {
+		r := gin.Default()
+		r.GET("/healthz", h.Healthz)
+		r.GET("/readyz", h.Readyz)
+		return doRequest(r, http.MethodGet, url, "", nil)
+	}
+
+	t.Run("Healthz - Liveness", func(t *testing.T) {
+		t.Log("Запуск: Healthz - Проверка живости")
+		w := probe(newHealthHandler(testDB), "/healthz")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.HealthResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "ok", response.Status)
+	})
+
+	t.Run("Readyz - Ready", func(t *testing.T) {
+		t.Log("Запуск: Readyz - База доступна, миграции применены")
+		w := probe(newHealthHandler(testDB), "/readyz")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.ReadinessResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "ok", response.Status)
+		assert.Equal(t, "ok", response.Database)
+		assert.Equal(t, "ok", response.Migrations.Status)
+		assert.Zero(t, response.Migrations.Pending)
+		assert.NotZero(t, response.Migrations.Version)
+	})
+
+	t.Run("Readyz - Pending migrations", func(t *testing.T) {
+		t.Log("Запуск: Readyz - Есть непримененные миграции")
+		w := probe(newHealthHandler(emptyDB()), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response models.ReadinessResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "unavailable", response.Status)
+		assert.Equal(t, "ok", response.Database)
+		assert.Equal(t, "pending", response.Migrations.Status)
+		assert.NotZero(t, response.Migrations.Pending)
+	})
+
+	t.Run("Readyz - Database unavailable", func(t *testing.T) {
+		t.Log("Запуск: Readyz - База данных недоступна")
+		closedDB := emptyDB()
+		h := newHealthHandler(closedDB)
+		sqlDB, _ := closedDB.DB()
+		sqlDB.Close()
+
+		w := probe(h, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response models.ReadinessResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "unavailable", response.Status)
+		assert.Equal(t, "unavailable", response.Database)
+		assert.Equal(t, "unknown", response.Migrations.Status)
+		assert.NotContains(t, w.Body.String(), "closed", "Текст ошибки драйвера не раскрывается")
+	})
+
+	t.Run("Readyz - Draining", func(t *testing.T) {
+		t.Log("Запуск: Readyz - Сервер останавливается")
+		h := newHealthHandler(testDB)
+		h.Drain()
+		w := probe(h, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response models.ReadinessResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "draining", response.Status)
+
+		w = probe(h, "/healthz")
+		assert.Equal(t, http.StatusOK, w.Code, "Живость не зависит от остановки")
+	})
+}
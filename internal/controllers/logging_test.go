@@ -0,0 +1,194 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/logging"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogging(t *testing.T) {
+	testDB := setupTestDB(t)
+	authHandler := newAuthHandler(testDB)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(config.LogConfig{Level: slog.LevelDebug, Format: "json"}, &logs))
+	defer slog.SetDefault(previous)
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.RequestLogger(), middleware.Recovery())
+	r.POST("/login", authHandler.Login)
+	r.GET("/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), noteHandler.GetNotes)
+	r.GET("/panic", func(c *gin.Context) { panic("сбой обработчика") })
+	r.GET("/public/:token", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_logging", "logging@example.com", "password123")
+
+	// doLoggedRequest выполняет запрос с ID requestID, оставляя в журнале только его записи
+	doLoggedRequest := func(method, url, token, requestID string, body interface{}) *httptest.ResponseRecorder {
+		req := newRequest(method, url, token, body)
+		if requestID != "" {
+			req.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		logs.Reset()
+		return serve(r, req)
+	}
+	// logEntries разбирает записи журнала последнего запроса с сообщением msg
+	logEntries := func(msg string) []map[string]any {
+		entries := []map[string]any{}
+		for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+			var entry map[string]any
+			if json.Unmarshal([]byte(line), &entry) == nil && entry["msg"] == msg {
+				entries = append(entries, entry)
+			}
+		}
+		return entries
+	}
+
+	t.Run("Request ID - Generated", func(t *testing.T) {
+		t.Log("Запуск: Request ID - Создается, если клиент его не передал")
+		w := doLoggedRequest(http.MethodGet, "/notes", token, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		requestID := w.Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, requestID, 32)
+
+		entries := logEntries("HTTP-запрос")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, requestID, entries[0]["request_id"])
+			assert.Equal(t, float64(userID), entries[0]["user_id"], "После AuthMiddleware в записи есть пользователь")
+			assert.Equal(t, "/notes", entries[0]["route"])
+			assert.Equal(t, float64(http.StatusOK), entries[0]["status"])
+			assert.Equal(t, "INFO", entries[0]["level"])
+		}
+	})
+
+	t.Run("Request ID - Propagated", func(t *testing.T) {
+		t.Log("Запуск: Request ID - Идентификатор клиента передается дальше")
+		w := doLoggedRequest(http.MethodGet, "/notes", token, "proxy-id-123", nil)
+		assert.Equal(t, "proxy-id-123", w.Header().Get(middleware.RequestIDHeader))
+		entries := logEntries("HTTP-запрос")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "proxy-id-123", entries[0]["request_id"])
+		}
+
+		w = doLoggedRequest(http.MethodGet, "/notes", token, "bad id\twith spaces", nil)
+		assert.Len(t, w.Header().Get(middleware.RequestIDHeader), 32, "Недопустимый идентификатор заменяется новым")
+	})
+
+	t.Run("Request ID - In error responses", func(t *testing.T) {
+		t.Log("Запуск: Request ID - Ответ с ошибкой содержит request_id")
+		w := doLoggedRequest(http.MethodGet, "/notes", "", "err-id-1", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var response map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "err-id-1", response["request_id"])
+		assert.NotEmpty(t, response["error"])
+
+		entries := logEntries("HTTP-запрос")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "WARN", entries[0]["level"])
+			assert.Nil(t, entries[0]["user_id"], "Без авторизации пользователь неизвестен")
+		}
+
+		w = doLoggedRequest(http.MethodGet, "/notes", token, "ok-id-1", nil)
+		assert.NotContains(t, w.Body.String(), "request_id", "Успешные ответы не меняются")
+	})
+
+	t.Run("Logging - Secrets redacted", func(t *testing.T) {
+		t.Log("Запуск: Logging - Пароли и токены не попадают в журнал")
+		w := doLoggedRequest(http.MethodPost, "/login", "", "", models.LoginInput{Identifier: "testuser_logging", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var tokens models.TokenPair
+		json.Unmarshal(w.Body.Bytes(), &tokens)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		assert.NotContains(t, logs.String(), "password123")
+		assert.NotContains(t, logs.String(), tokens.AccessToken)
+		assert.NotContains(t, logs.String(), tokens.RefreshToken)
+		entries := logEntries("HTTP-запрос")
+		if assert.Len(t, entries, 1) {
+			assert.Contains(t, entries[0]["body"], "testuser_logging")
+			assert.Contains(t, entries[0]["body"], logging.Redacted)
+		}
+	})
+
+	t.Run("Logging - Panic recovered", func(t *testing.T) {
+		t.Log("Запуск: Logging - Паника обработчика записывается в журнал")
+		w := doLoggedRequest(http.MethodGet, "/panic", "", "panic-id", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), `"request_id":"panic-id"`)
+
+		panics := logEntries("Паника при обработке запроса")
+		if assert.Len(t, panics, 1) {
+			assert.Equal(t, "сбой обработчика", panics[0]["panic"])
+			assert.Equal(t, "panic-id", panics[0]["request_id"])
+			assert.NotEmpty(t, panics[0]["stack"])
+		}
+		entries := logEntries("HTTP-запрос")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "ERROR", entries[0]["level"])
+		}
+	})
+
+	t.Run("Logging - Share link token redacted", func(t *testing.T) {
+		t.Log("Запуск: Logging - Токен публичной ссылки не попадает в журнал")
+		w := doLoggedRequest(http.MethodGet, "/public/share-secret-token", "", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		assert.NotContains(t, logs.String(), "share-secret-token")
+		entries := logEntries("HTTP-запрос")
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "/public/:token", entries[0]["route"])
+			assert.Equal(t, "/public/"+logging.Redacted, entries[0]["path"])
+		}
+	})
+}
+
+func TestRedactBody(t *testing.T) {
+	t.Run("Redact - Path", func(t *testing.T) {
+		t.Log("Запуск: Redact - Секретные параметры пути")
+		assert.Equal(t, "/public/"+logging.Redacted, logging.RedactPath("/public/:token", "/public/abc"))
+		assert.Equal(t, "/notes/42/revisions/3", logging.RedactPath("/notes/:id/revisions/:rev", "/notes/42/revisions/3"))
+		assert.Equal(t, "/files/"+logging.Redacted, logging.RedactPath("/files/*secret", "/files/a/b"))
+		assert.Equal(t, "/unknown/abc", logging.RedactPath("", "/unknown/abc"))
+	})
+
+	t.Run("Redact - JSON", func(t *testing.T) {
+		t.Log("Запуск: Redact - Вложенные поля JSON")
+		body := logging.RedactBody("application/json; charset=utf-8",
+			[]byte(`{"username":"bob","new_password":"s3cret","mfa":{"code":"123456"},"items":[{"refresh_token":"abc"}]}`))
+		assert.NotContains(t, body, "s3cret")
+		assert.NotContains(t, body, "123456")
+		assert.NotContains(t, body, "abc")
+		assert.Contains(t, body, `"username":"bob"`)
+	})
+
+	t.Run("Redact - Form", func(t *testing.T) {
+		t.Log("Запуск: Redact - Поля формы")
+		body := logging.RedactBody("application/x-www-form-urlencoded", []byte("password=s3cret&lang=ru"))
+		assert.NotContains(t, body, "s3cret")
+		assert.Contains(t, body, "lang=ru")
+	})
+
+	t.Run("Redact - Other content", func(t *testing.T) {
+		t.Log("Запуск: Redact - Тела других типов не выводятся")
+		body := logging.RedactBody("text/plain", []byte("password=s3cret"))
+		assert.NotContains(t, body, "s3cret")
+	})
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/metrics"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// loginAttemptSortColumns — поля, по которым можно сортировать журнал попыток входа.
+var loginAttemptSortColumns = map[string]string{
+	"created_at": "created_at",
+}
+
+// rejectLockedOut записывает попытку входа во время блокировки и отвечает 429 с Retry-After.
+func (h *AuthHandler) rejectLockedOut(c *gin.Context, userID *uint, identifier, ip string, retryAfter time.Duration) {
+	metrics.LoginAttempt(models.LoginResultLockedOut)
+	if err := auth.RecordLoginAttempt(withRequest(c, h.db), userID, identifier, ip, models.LoginResultLockedOut); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Не удалось записать попытку входа", "error", err)
+	}
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Слишком много неудачных попыток входа, повторите попытку позже"})
+}
+
+// checkIPAllowed проверяет, не превышен ли лимит неудачных попыток входа с адреса клиента.
+// Адрес берется из c.ClientIP: X-Forwarded-For учитывается только от доверенных прокси
+// (SERVER_TRUSTED_PROXIES, см. routes.NewEngine), иначе лимит обходился бы подменой заголовка.
+// При ошибке ответ уже отправлен.
+func (h *AuthHandler) checkIPAllowed(c *gin.Context, identifier, ip string) bool {
+	retryAfter, err := auth.IPRetryAfter(withRequest(c, h.db), h.policy, ip, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки попыток входа"})
+		return false
+	}
+	if retryAfter > 0 {
+		h.rejectLockedOut(c, nil, identifier, ip, retryAfter)
+		return false
+	}
+	return true
+}
+
+// checkAccountUnlocked проверяет, что вход в учетную запись не заблокирован после серии неудачных попыток.
+// Пока блокировка действует, пароль и коды не проверяются. При ошибке ответ уже отправлен.
+func (h *AuthHandler) checkAccountUnlocked(c *gin.Context, user models.User, identifier, ip string) bool {
+	if retryAfter := auth.LockoutRemaining(user, time.Now()); retryAfter > 0 {
+		h.rejectLockedOut(c, &user.ID, identifier, ip, retryAfter)
+		return false
+	}
+	return true
+}
+
+// registerLoginFailure учитывает неудачную попытку входа. Ошибка записи не мешает ответить клиенту.
+func (h *AuthHandler) registerLoginFailure(ctx context.Context, user *models.User, identifier, ip, result string) {
+	metrics.LoginAttempt(result)
+	if user == nil {
+		if err := auth.RecordLoginAttempt(h.db.WithContext(ctx), nil, identifier, ip, result); err != nil {
+			slog.ErrorContext(ctx, "Не удалось записать попытку входа", "error", err)
+		}
+		return
+	}
+	if err := auth.RegisterLoginFailure(h.db.WithContext(ctx), h.policy, user, identifier, ip, result, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "Не удалось учесть неудачную попытку входа", "account_id", user.ID, "error", err)
+	}
+}
+
+// registerLoginSuccess сбрасывает счетчик неудачных попыток после успешного входа.
+func (h *AuthHandler) registerLoginSuccess(ctx context.Context, user *models.User, identifier, ip string) {
+	metrics.LoginAttempt(models.LoginResultSuccess)
+	if err := auth.RegisterLoginSuccess(h.db.WithContext(ctx), user, identifier, ip); err != nil {
+		slog.ErrorContext(ctx, "Не удалось записать вход", "account_id", user.ID, "error", err)
+	}
+}
+
+// respondLoginAttempts отправляет страницу журнала попыток входа пользователя.
+func respondLoginAttempts(c *gin.Context, tx *gorm.DB, userID uint) {
+	params, err := parseListParams(c, loginAttemptSortColumns, "created_at")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query := tx.Model(&models.LoginAttempt{}).Where("login_attempts.user_id = ?", userID)
+	switch result := c.Query("result"); result {
+	case "":
+	case models.LoginResultSuccess, models.LoginResultWrongPassword, models.LoginResultWrongCode, models.LoginResultLockedOut:
+		query = query.Where("login_attempts.result = ?", result)
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Недопустимый результат попытки: " + result})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении попыток входа"})
+		return
+	}
+
+	attempts := []models.LoginAttempt{}
+	if err := paginate(query, params, loginAttemptSortColumns, "login_attempts").Find(&attempts).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении попыток входа"})
+		return
+	}
+
+	var nextCursor string
+	if len(attempts) > params.Limit {
+		attempts = attempts[:params.Limit]
+		last := attempts[len(attempts)-1]
+		nextCursor = encodeCursor(pageCursor{
+			Sort:  params.Sort,
+			Order: params.Order,
+			Value: last.CreatedAt.Format(time.RFC3339Nano),
+			ID:    last.ID,
+		})
+	}
+
+	setPaginationHeaders(c, total, nextCursor)
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetLoginAttempts godoc
+// @Summary История входов
+// @Description Возвращает попытки входа в учетную запись текущего пользователя: успешные (success), с неверным паролем (wrong_password), с неверным кодом подтверждения (wrong_code) и отклоненные из-за блокировки (locked_out).
+// @Tags users
+// @Produce json
+// @Param result query string false "Результат попытки" Enums(success, wrong_password, wrong_code, locked_out)
+// @Param limit query int false "Размер страницы (по умолчанию 20, не более 100)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(desc)
+// @Security ApiKeyAuth
+// @Success 200 {array} models.LoginAttempt
+// @Header 200 {integer} X-Total-Count "Общее количество попыток"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} models.ErrorResponse
+// @Router /users/me/login-attempts [get]
+func (h *UserHandler) GetLoginAttempts(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	respondLoginAttempts(c, withRequest(c, h.db), userID)
+}
+
+// AdminGetLoginAttempts godoc
+// @Summary История входов пользователя
+// @Description Возвращает попытки входа в учетную запись пользователя. Доступно только администраторам.
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param result query string false "Результат попытки" Enums(success, wrong_password, wrong_code, locked_out)
+// @Param limit query int false "Размер страницы (по умолчанию 20, не более 100)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(desc)
+// @Security ApiKeyAuth
+// @Success 200 {array} models.LoginAttempt
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/login-attempts [get]
+func (h *AdminHandler) AdminGetLoginAttempts(c *gin.Context) {
+	user, ok := h.findAdminTarget(c)
+	if !ok {
+		return
+	}
+	respondLoginAttempts(c, withRequest(c, h.db), user.ID)
+}
+
+// AdminUnlockUser godoc
+// @Summary Снять блокировку входа
+// @Description Снимает временную блокировку после неудачных попыток входа и обнуляет их счетчик.
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminUser
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/users/{id}/unlock [post]
+func (h *AdminHandler) AdminUnlockUser(c *gin.Context) {
+	user, ok := h.findAdminTarget(c)
+	if !ok {
+		return
+	}
+	if err := auth.Unlock(withRequest(c, h.db), user.ID); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось снять блокировку"})
+		return
+	}
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	h.respondAdminUser(c, user)
+}
@@ -0,0 +1,154 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockout(t *testing.T) {
+	testDB := setupTestDB(t)
+	userHandler := newUserHandler(testDB)
+	adminHandler := newAdminHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	cfg := testConfig()
+	cfg.Login.MaxFailures = 3
+	cfg.Login.IPMaxFailures = 5
+	authHandler := controllers.NewAuthHandler(testDB, mailer.NewOutbox(testDB), cfg)
+
+	r, err := routes.NewEngine(testConfig().Server)
+	assert.NoError(t, err)
+	r.POST("/auth/login", authHandler.Login)
+	r.GET("/users/me/login-attempts", middleware.AuthMiddleware(testDB, testConfig().JWT), userHandler.GetLoginAttempts)
+	admin := r.Group("/admin").Use(middleware.AuthMiddleware(testDB, testConfig().JWT), middleware.RequireRole(models.RoleAdmin))
+	admin.POST("/users/:id/unlock", adminHandler.AdminUnlockUser)
+	admin.GET("/users/:id/login-attempts", adminHandler.AdminGetLoginAttempts)
+
+	adminToken, adminID := registerAndLoginUser(t, testDB, "testuser_lockout_admin", "lockout_admin@example.com", "password123")
+	testDB.Model(&models.User{}).Where("id = ?", adminID).Update("role", models.RoleAdmin)
+	token, userID := registerAndLoginUser(t, testDB, "testuser_lockout", "lockout@example.com", "password123")
+	userPath := "/admin/users/" + strconv.FormatUint(uint64(userID), 10)
+
+	var forwardedFor string
+	doRequestFrom := func(method, url, token, ip string, body interface{}) *httptest.ResponseRecorder {
+		req := newRequest(method, url, token, body)
+		req.RemoteAddr = ip + ":40000"
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return serve(r, req)
+	}
+	login := func(password, ip string) *httptest.ResponseRecorder {
+		return doRequestFrom(http.MethodPost, "/auth/login", "", ip, models.LoginInput{Identifier: "testuser_lockout", Password: password})
+	}
+
+	t.Run("Login - Account lockout after failures", func(t *testing.T) {
+		t.Log("Запуск: Login - Блокировка после серии неудачных попыток")
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("wrong_password", "198.51.100.1").Code)
+		}
+
+		w := login("password123", "198.51.100.1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Во время блокировки не принимается даже верный пароль")
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		assert.NoError(t, err)
+		assert.InDelta(t, 60, retryAfter, 2, "Первая блокировка длится LOGIN_LOCKOUT_BASE")
+
+		var user models.User
+		testDB.First(&user, userID)
+		assert.Equal(t, 3, user.FailedLogins)
+		assert.NotNil(t, user.LockedUntil)
+	})
+
+	t.Run("Login - Exponential backoff", func(t *testing.T) {
+		t.Log("Запуск: Login - Каждая следующая неудача удваивает блокировку")
+		testDB.Model(&models.User{}).Where("id = ?", userID).Update("locked_until", nil)
+		assert.Equal(t, http.StatusUnauthorized, login("wrong_password", "198.51.100.2").Code)
+
+		w := login("password123", "198.51.100.2")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+		assert.InDelta(t, 120, retryAfter, 2)
+	})
+
+	t.Run("AdminUnlockUser - Login works again", func(t *testing.T) {
+		t.Log("Запуск: AdminUnlockUser - Снятие блокировки администратором")
+		assert.Equal(t, http.StatusForbidden, doRequestFrom(http.MethodPost, userPath+"/unlock", token, "198.51.100.3", nil).Code)
+
+		w := doRequestFrom(http.MethodPost, userPath+"/unlock", adminToken, "198.51.100.3", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var unlocked models.AdminUser
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &unlocked))
+		assert.Nil(t, unlocked.LockedUntil)
+
+		assert.Equal(t, http.StatusOK, login("password123", "198.51.100.3").Code)
+		var user models.User
+		testDB.First(&user, userID)
+		assert.Zero(t, user.FailedLogins, "Успешный вход обнуляет счетчик")
+	})
+
+	t.Run("Login - Per-IP limit", func(t *testing.T) {
+		t.Log("Запуск: Login - Ограничение попыток с одного адреса")
+		for i := 0; i < 5; i++ {
+			w := doRequestFrom(http.MethodPost, "/auth/login", "", "203.0.113.7", models.LoginInput{Identifier: "nobody_" + strconv.Itoa(i), Password: "password123"})
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+		w := login("password123", "203.0.113.7")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, login("password123", "203.0.113.8").Code, "Другие адреса не ограничены")
+	})
+
+	t.Run("Login - Per-IP limit with forged X-Forwarded-For", func(t *testing.T) {
+		t.Log("Запуск: Login - Подмена X-Forwarded-For не обходит ограничение по адресу")
+		defer func() { forwardedFor = "" }()
+		for i := 0; i < 5; i++ {
+			forwardedFor = "192.0.2." + strconv.Itoa(100+i)
+			w := doRequestFrom(http.MethodPost, "/auth/login", "", "203.0.113.9", models.LoginInput{Identifier: "forged_" + strconv.Itoa(i), Password: "password123"})
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+		forwardedFor = "192.0.2.200"
+		w := login("password123", "203.0.113.9")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Без доверенных прокси учитывается адрес соединения")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("GetLoginAttempts - History", func(t *testing.T) {
+		t.Log("Запуск: GetLoginAttempts - Журнал попыток входа")
+		w := doRequestFrom(http.MethodGet, "/users/me/login-attempts?order=asc", token, "198.51.100.4", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var attempts []models.LoginAttempt
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attempts))
+		results := make([]string, len(attempts))
+		for i, attempt := range attempts {
+			results[i] = attempt.Result
+		}
+		assert.Equal(t, []string{
+			models.LoginResultWrongPassword, models.LoginResultWrongPassword, models.LoginResultWrongPassword,
+			models.LoginResultLockedOut,
+			models.LoginResultWrongPassword, models.LoginResultLockedOut,
+			models.LoginResultSuccess, models.LoginResultSuccess,
+		}, results)
+
+		w = doRequestFrom(http.MethodGet, userPath+"/login-attempts?result=locked_out", adminToken, "198.51.100.4", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+
+		w = doRequestFrom(http.MethodGet, "/users/me/login-attempts?result=unknown", token, "198.51.100.4", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -1,13 +1,24 @@
 package controllers_test
 
 import (
-	"os"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
-	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/internal/migrate"
+	"github.com/heebit/notes-api/internal/repository"
+	"github.com/heebit/notes-api/internal/service"
+	"github.com/heebit/notes-api/migrations"
 	"github.com/heebit/notes-api/models"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
@@ -15,41 +26,62 @@ import (
 	"gorm.io/gorm"
 )
 
-func setupTestDB() *gorm.DB {
+// setupTestDB открывает отдельную базу в памяти для теста t: тесты не видят данных друг друга
+// и могут выполняться параллельно. База существует, пока открыто подключение.
+func setupTestDB(t *testing.T) *gorm.DB {
 	gin.SetMode(gin.TestMode)
 
-	testDB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	dsn := "file:" + url.PathEscape(t.Name()) + "?mode=memory&cache=shared"
+	testDB, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		panic("Не удалось подключиться к тестовой базе данных")
 	}
-	if err := testDB.AutoMigrate(&models.User{}, &models.Note{}); err != nil {
-		panic("Не удалось выполнить миграцию тестовой базы данных")
+	if err := migrateTestDB(testDB); err != nil {
+		panic("Не удалось выполнить миграцию тестовой базы данных (тесты запускаются с -tags sqlite_fts5): " + err.Error())
 	}
-	db.DB = testDB
 	return testDB
 }
 
-func TestMain(m *testing.M) {
-	// Установите тестовые переменные среды
-	os.Setenv("JWT_SECRET", "supersecretkeyfortesting")
+// testConfig возвращает настройки по умолчанию с тестовым ключом подписи JWT. Каждый вызов
+// создает новый экземпляр, так что тест может менять его, не затрагивая другие.
+func testConfig() *config.Config {
+	cfg := config.Defaults()
+	cfg.JWT.Secret = "supersecretkeyfortesting"
+	return cfg
+}
 
-	// Настройте тестовую базу данных
-	gin.SetMode(gin.TestMode)
-	testDB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+// migrateTestDB применяет к тестовой базе те же миграции SQLite, что и в production.
+func migrateTestDB(testDB *gorm.DB) error {
+	sqlDB, err := testDB.DB()
 	if err != nil {
-		panic("Не удалось подключиться к тестовой базе данных")
+		return err
 	}
-	if err := testDB.AutoMigrate(&models.User{}, &models.Note{}); err != nil {
-		panic("Не удалось выполнить миграцию тестовой базы данных")
+	migrator, err := migrate.New(sqlDB, "sqlite", migrations.FS)
+	if err != nil {
+		return err
 	}
-	db.DB = testDB
+	_, err = migrator.Up(context.Background())
+	return err
+}
+
+// newNoteHandler собирает NoteHandler поверх тестовой базы так же, как main.
+func newNoteHandler(testDB *gorm.DB) *controllers.NoteHandler {
+	return controllers.NewNoteHandler(testDB, service.NewNoteService(repository.NewNoteRepository(testDB)))
+}
+
+// newUserHandler собирает UserHandler, письма которого попадают в outbox тестовой базы.
+func newUserHandler(testDB *gorm.DB) *controllers.UserHandler {
+	return controllers.NewUserHandler(testDB, service.NewUserService(repository.NewUserRepository(testDB)), mailer.NewOutbox(testDB), testConfig())
+}
 
-	// Запустите тесты
-	code := m.Run()
+// newAuthHandler собирает AuthHandler, письма которого попадают в outbox тестовой базы.
+func newAuthHandler(testDB *gorm.DB) *controllers.AuthHandler {
+	return controllers.NewAuthHandler(testDB, mailer.NewOutbox(testDB), testConfig())
+}
 
-	// Очистка
-	os.Unsetenv("JWT_SECRET")
-	os.Exit(code)
+// newAdminHandler собирает AdminHandler, письма которого попадают в outbox тестовой базы.
+func newAdminHandler(testDB *gorm.DB) *controllers.AdminHandler {
+	return controllers.NewAdminHandler(testDB, mailer.NewOutbox(testDB), testConfig())
 }
 
 // registerAndLoginUser - вспомогательная функция для создания тестового пользователя
@@ -73,8 +105,36 @@ func registerAndLoginUser(t *testing.T, testDB *gorm.DB, username, email, passwo
 		"user_id": float64(user.ID),                      // JWT claims обычно используют float64 для чисел
 		"exp":     time.Now().Add(time.Hour * 24).Unix(), // Токен действует 24 часа
 	})
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokenString, err := token.SignedString([]byte(testConfig().JWT.Secret))
 	assert.NoError(t, err)
 
 	return tokenString, user.ID
 }
+
+// newRequest собирает запрос к API: body, если он не nil, передается как JSON,
+// непустой token — в заголовке Authorization.
+func newRequest(method, url, token string, body interface{}) *http.Request {
+	payload := bytes.NewBuffer(nil)
+	if body != nil {
+		jsonValue, _ := json.Marshal(body)
+		payload = bytes.NewBuffer(jsonValue)
+	}
+	req, _ := http.NewRequest(method, url, payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// serve выполняет запрос req на роутере r и возвращает записанный ответ.
+func serve(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// doRequest выполняет на роутере r запрос, собранный newRequest.
+func doRequest(r http.Handler, method, url, token string, body interface{}) *httptest.ResponseRecorder {
+	return serve(r, newRequest(method, url, token, body))
+}
@@ -0,0 +1,94 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/metrics"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	testDB := setupTestDB(t)
+	authHandler := newAuthHandler(testDB)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.Metrics())
+	r.POST("/login", authHandler.Login)
+	r.POST("/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), noteHandler.CreateNote)
+	r.GET("/notes/:id", middleware.AuthMiddleware(testDB, testConfig().JWT), noteHandler.GetNote)
+	routes.MetricsRoutes(r)
+
+	token, _ := registerAndLoginUser(t, testDB, "testuser_metrics", "metrics@example.com", "password123")
+
+	scrape := func() string {
+		w := doRequest(r, http.MethodGet, "/metrics", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	t.Run("Metrics - HTTP requests by route template", func(t *testing.T) {
+		t.Log("Запуск: Metrics - Запросы учитываются по шаблону маршрута")
+		w := doRequest(r, http.MethodPost, "/notes", token, models.NoteInput{Title: "Метрики", Content: "Текст"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var note models.Note
+		json.Unmarshal(w.Body.Bytes(), &note)
+		doRequest(r, http.MethodGet, "/notes/"+strconv.FormatUint(uint64(note.ID), 10), token, nil)
+		doRequest(r, http.MethodGet, "/notes/999999", token, nil)
+		doRequest(r, http.MethodGet, "/no/such/path", "", nil)
+
+		body := scrape()
+		assert.Contains(t, body, `http_requests_total{method="POST",route="/notes",status="201"}`)
+		assert.Contains(t, body, `http_requests_total{method="GET",route="/notes/:id",status="200"}`)
+		assert.Contains(t, body, `http_requests_total{method="GET",route="/notes/:id",status="404"}`)
+		assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"}`)
+		assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/notes/:id",status="200"`)
+		assert.NotContains(t, body, `route="/notes/999999"`, "Фактический путь не становится меткой")
+	})
+
+	t.Run("Metrics - Logins and password hashing", func(t *testing.T) {
+		t.Log("Запуск: Metrics - Успешные и неудачные входы, время bcrypt")
+		w := doRequest(r, http.MethodPost, "/login", "", models.LoginInput{Identifier: "testuser_metrics", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doRequest(r, http.MethodPost, "/login", "", models.LoginInput{Identifier: "testuser_metrics", Password: "wrongpassword"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		body := scrape()
+		assert.Contains(t, body, `auth_login_attempts_total{result="success"}`)
+		assert.Contains(t, body, `auth_login_attempts_total{result="wrong_password"}`)
+		assert.Contains(t, body, `password_hash_duration_seconds_count{operation="compare"}`)
+	})
+
+	t.Run("Metrics - Business gauges", func(t *testing.T) {
+		t.Log("Запуск: Metrics - Показатели заметок и пользователей")
+		// Тестовая база общая для всех тестов, поэтому ожидаемые значения считаются по ней же
+		now := time.Now()
+		var notes, created, users int64
+		testDB.Model(&models.Note{}).Count(&notes)
+		testDB.Unscoped().Model(&models.Note{}).Where("created_at >= ?", now.Add(-time.Minute)).Count(&created)
+		testDB.Model(&models.User{}).Count(&users)
+		assert.NotZero(t, created, "Заметка из первого подтеста создана меньше минуты назад")
+
+		assert.NoError(t, metrics.UpdateStats(testDB, now))
+		body := scrape()
+		assert.Contains(t, body, fmt.Sprintf("notes_created_per_minute %d\n", created))
+		assert.Contains(t, body, fmt.Sprintf("notes_total %d\n", notes))
+		assert.Contains(t, body, fmt.Sprintf("users_total %d\n", users))
+
+		assert.NoError(t, metrics.UpdateStats(testDB, time.Now().Add(2*time.Minute)))
+		assert.Contains(t, scrape(), "notes_created_per_minute 0\n", "Учитываются только заметки за последнюю минуту")
+	})
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/totp"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// loadCurrentUser загружает пользователя из токена. При ошибке ответ уже отправлен.
+func (h *AuthHandler) loadCurrentUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return user, false
+	}
+	if err := withRequest(c, h.db).First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return user, false
+	}
+	return user, true
+}
+
+// errInvalidMFACode откатывает транзакцию, если код подтверждения не подошел.
+var errInvalidMFACode = errors.New("invalid mfa code")
+
+// respondMFAError отправляет ответ об ошибке транзакции с проверкой кода. Возвращает true, если ошибки нет.
+func respondMFAError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный код подтверждения"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при настройке двухфакторной аутентификации"})
+	}
+	return false
+}
+
+// SetupMFA godoc
+// @Summary Начать подключение двухфакторной аутентификации
+// @Description Создает новый TOTP-секрет и возвращает его вместе с otpauth-ссылкой и QR-кодом. Двухфакторная аутентификация включается только после подтверждения кодом в /auth/mfa/enable.
+// @Tags mfa
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MFASetup
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/mfa/setup [post]
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Двухфакторная аутентификация уже включена"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать секрет"})
+		return
+	}
+	uri := totp.URI(h.cfg.Auth.MFAIssuer, user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать QR-код"})
+		return
+	}
+	if err := withRequest(c, h.db).Model(&user).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сохранить секрет"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFASetup{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// EnableMFA godoc
+// @Summary Включить двухфакторную аутентификацию
+// @Description Подтверждает секрет из /auth/mfa/setup кодом из приложения и возвращает коды восстановления. Коды показываются один раз.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param input body models.MFACodeInput true "Код из приложения"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RecoveryCodes
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/mfa/enable [post]
+func (h *AuthHandler) EnableMFA(c *gin.Context) {
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Двухфакторная аутентификация уже включена"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Сначала получите секрет через /auth/mfa/setup"})
+		return
+	}
+
+	var codes []string
+	err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		valid, err := auth.VerifySecondFactor(tx, &user, input.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errInvalidMFACode
+		}
+		if err := tx.Model(&user).Update("totp_enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+		codes, err = auth.GenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if !respondMFAError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, models.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// DisableMFA godoc
+// @Summary Отключить двухфакторную аутентификацию
+// @Description Требует пароль и действующий код из приложения или код восстановления
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param input body models.DisableMFAInput true "Пароль и код"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/mfa/disable [post]
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var input models.DisableMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Двухфакторная аутентификация не включена"})
+		return
+	}
+	if err := utils.ComparePassword(user.Password, input.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный пароль"})
+		return
+	}
+
+	err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		valid, err := auth.VerifySecondFactor(tx, &user, input.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errInvalidMFACode
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+	})
+	if !respondMFAError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Двухфакторная аутентификация отключена"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Новые коды восстановления
+// @Description Заменяет все коды восстановления новыми. Требует действующий код из приложения.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param input body models.MFACodeInput true "Код из приложения"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RecoveryCodes
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/mfa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Двухфакторная аутентификация не включена"})
+		return
+	}
+
+	var codes []string
+	err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		valid, err := auth.VerifySecondFactor(tx, &user, input.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errInvalidMFACode
+		}
+		codes, err = auth.GenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if !respondMFAError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, models.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// LoginMFA godoc
+// @Summary Второй шаг входа
+// @Description Обменивает mfa_token из ответа /auth/login и код из приложения (или код восстановления) на пару токенов
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.MFALoginInput true "Токен первого шага и код"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Router /auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var input models.MFALoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+	userID, err := utils.ParseMFAToken(h.cfg.JWT, input.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Сессия входа истекла, войдите заново"})
+		return
+	}
+	var user models.User
+	if err := withRequest(c, h.db).First(&user, userID).Error; err != nil || user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Сессия входа истекла, войдите заново"})
+		return
+	}
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Учетная запись заблокирована"})
+		return
+	}
+
+	// Неверные коды учитываются вместе с неверными паролями, иначе код можно было бы подобрать
+	ip := c.ClientIP()
+	if !h.checkIPAllowed(c, user.Username, ip) || !h.checkAccountUnlocked(c, user, user.Username, ip) {
+		return
+	}
+
+	valid, err := auth.VerifySecondFactor(withRequest(c, h.db), &user, input.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки кода"})
+		return
+	}
+	if !valid {
+		h.registerLoginFailure(c.Request.Context(), &user, user.Username, ip, models.LoginResultWrongCode)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный код подтверждения"})
+		return
+	}
+	h.registerLoginSuccess(c.Request.Context(), &user, user.Username, ip)
+
+	tokens, err := auth.IssueTokens(withRequest(c, h.db), h.cfg.JWT, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токена"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,140 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/totp"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFA(t *testing.T) {
+	testDB := setupTestDB(t)
+	authHandler := newAuthHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.POST("/auth/login", authHandler.Login)
+	r.POST("/auth/login/mfa", authHandler.LoginMFA)
+	mfa := r.Group("/auth/mfa").Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	mfa.POST("/setup", authHandler.SetupMFA)
+	mfa.POST("/enable", authHandler.EnableMFA)
+	mfa.POST("/disable", authHandler.DisableMFA)
+	mfa.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+	token, _ := registerAndLoginUser(t, testDB, "testuser_mfa", "mfa@example.com", "password123")
+
+	// Каждый код принимается один раз, поэтому для следующего запроса берется следующий допустимый шаг
+	var secret string
+	var lastStep int64
+	nextCode := func(t *testing.T) string {
+		current := totp.Step(time.Now())
+		step := current - totp.Skew
+		if step <= lastStep {
+			step = lastStep + 1
+		}
+		lastStep = step
+		code, err := totp.CodeAt(secret, step)
+		assert.NoError(t, err)
+		return code
+	}
+	login := func(t *testing.T) models.MFAChallenge {
+		w := doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_mfa", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var challenge models.MFAChallenge
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+		return challenge
+	}
+
+	var recoveryCodes []string
+
+	t.Run("SetupMFA - Secret, URI and QR code", func(t *testing.T) {
+		t.Log("Запуск: SetupMFA - Выдача секрета")
+		w := doRequest(r, http.MethodPost, "/auth/mfa/setup", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var setup models.MFASetup
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+		assert.NotEmpty(t, setup.Secret)
+		assert.True(t, strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/"))
+		assert.Contains(t, setup.OTPAuthURI, "secret="+setup.Secret)
+		assert.True(t, strings.HasPrefix(setup.QRCode, "data:image/png;base64,"))
+		secret = setup.Secret
+
+		w = doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_mfa", Password: "password123"})
+		assert.Contains(t, w.Body.String(), "access_token", "До подтверждения кодом вход остается одношаговым")
+	})
+
+	t.Run("EnableMFA - Confirm with code", func(t *testing.T) {
+		t.Log("Запуск: EnableMFA - Подтверждение секрета кодом")
+		w := doRequest(r, http.MethodPost, "/auth/mfa/enable", token, models.MFACodeInput{Code: "000000"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doRequest(r, http.MethodPost, "/auth/mfa/enable", token, models.MFACodeInput{Code: nextCode(t)})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var codes models.RecoveryCodes
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &codes))
+		assert.Len(t, codes.RecoveryCodes, 10)
+		recoveryCodes = codes.RecoveryCodes
+
+		w = doRequest(r, http.MethodPost, "/auth/mfa/setup", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Секрет нельзя перевыпустить без отключения")
+	})
+
+	t.Run("Login - Two steps", func(t *testing.T) {
+		t.Log("Запуск: Login - Вход с кодом из приложения")
+		challenge := login(t)
+		assert.True(t, challenge.MFARequired)
+		assert.NotEmpty(t, challenge.MFAToken)
+
+		w := doRequest(r, http.MethodPost, "/auth/login/mfa", "", models.MFALoginInput{MFAToken: challenge.MFAToken, Code: "000000"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		code := nextCode(t)
+		w = doRequest(r, http.MethodPost, "/auth/login/mfa", "", models.MFALoginInput{MFAToken: challenge.MFAToken, Code: code})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "access_token")
+
+		w = doRequest(r, http.MethodPost, "/auth/login/mfa", "", models.MFALoginInput{MFAToken: challenge.MFAToken, Code: code})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Код нельзя использовать повторно")
+
+		w = doRequest(r, http.MethodPost, "/auth/login/mfa", "", models.MFALoginInput{MFAToken: token, Code: nextCode(t)})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Access-токен не заменяет токен первого шага")
+	})
+
+	t.Run("Login - Recovery code", func(t *testing.T) {
+		t.Log("Запуск: Login - Вход по коду восстановления")
+		challenge := login(t)
+		w := doRequest(r, http.MethodPost, "/auth/login/mfa", "", models.MFALoginInput{MFAToken: challenge.MFAToken, Code: strings.ToUpper(recoveryCodes[0])})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		challenge = login(t)
+		w = doRequest(r, http.MethodPost, "/auth/login/mfa", "", models.MFALoginInput{MFAToken: challenge.MFAToken, Code: recoveryCodes[0]})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Код восстановления одноразовый")
+	})
+
+	t.Run("DisableMFA - Requires password and code", func(t *testing.T) {
+		t.Log("Запуск: DisableMFA - Отключение двухфакторной аутентификации")
+		w := doRequest(r, http.MethodPost, "/auth/mfa/disable", token, models.DisableMFAInput{Password: "wrong", Code: recoveryCodes[1]})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = doRequest(r, http.MethodPost, "/auth/mfa/disable", token, models.DisableMFAInput{Password: "password123", Code: recoveryCodes[1]})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		testDB.Model(&models.RecoveryCode{}).Count(&count)
+		assert.Zero(t, count, "Коды восстановления удаляются вместе с секретом")
+
+		w = doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_mfa", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "access_token")
+	})
+}
@@ -0,0 +1,88 @@
+package controllers_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/heebit/notes-api/internal/migrate"
+	"github.com/heebit/notes-api/migrations"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "notes.db")
+	newMigrator := func() *migrate.Migrator {
+		gormDB, err := gorm.Open(sqlite.Open("file:"+path+"?_busy_timeout=5000"), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("не удалось открыть базу: %v", err)
+		}
+		sqlDB, _ := gormDB.DB()
+		t.Cleanup(func() { sqlDB.Close() })
+		migrator, err := migrate.New(sqlDB, "sqlite", migrations.FS)
+		if err != nil {
+			t.Fatalf("не удалось загрузить миграции: %v", err)
+		}
+		return migrator
+	}
+
+	t.Run("Migrations - Concurrent up", func(t *testing.T) {
+		t.Log("Запуск: Migrations - Одновременный запуск на нескольких экземплярах")
+		var wg sync.WaitGroup
+		results := make([][]migrate.Migration, 3)
+		for i := range results {
+			migrator := newMigrator()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var err error
+				results[i], err = migrator.Up(ctx)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		total := 0
+		for _, applied := range results {
+			total += len(applied)
+		}
+		statuses, err := newMigrator().Status(ctx)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, statuses)
+		assert.Equal(t, len(statuses), total, "Каждая миграция применена ровно один раз")
+
+		pending, err := newMigrator().Pending(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, pending)
+	})
+
+	t.Run("Migrations - Down and up again", func(t *testing.T) {
+		t.Log("Запуск: Migrations - Откат всех миграций и повторное применение")
+		migrator := newMigrator()
+		statuses, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+
+		for i := len(statuses) - 1; i >= 0; i-- {
+			reverted, err := migrator.Down(ctx)
+			if !assert.NoError(t, err) || !assert.NotNil(t, reverted) {
+				return
+			}
+			assert.Equal(t, statuses[i].Version, reverted.Version, "Откатывается последняя примененная миграция")
+		}
+		reverted, err := migrator.Down(ctx)
+		assert.NoError(t, err)
+		assert.Nil(t, reverted, "Откатывать больше нечего")
+
+		pending, err := migrator.Pending(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, len(statuses), pending)
+
+		applied, err := migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, applied, len(statuses))
+	})
+}
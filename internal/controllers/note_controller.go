@@ -1,15 +1,30 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/internal/search"
+	"github.com/heebit/notes-api/internal/service"
 	"github.com/heebit/notes-api/models"
 	"gorm.io/gorm"
 )
 
+// NoteHandler обрабатывает запросы к заметкам, их корзине, истории правок и доступам.
+type NoteHandler struct {
+	db    *gorm.DB
+	notes *service.NoteService
+}
+
+// NewNoteHandler создает NoteHandler. Правила работы с заметкой выполняет notes,
+// а tx используется для выборок списков, поиска и доступов.
+func NewNoteHandler(tx *gorm.DB, notes *service.NoteService) *NoteHandler {
+	return &NoteHandler{db: tx, notes: notes}
+}
+
 func getUserIdFromContext(c *gin.Context) (uint, bool) {
 	userId, exists := c.Get("user_id")
 	if !exists {
@@ -24,26 +39,203 @@ func getUserIdFromContext(c *gin.Context) (uint, bool) {
 	return id, true
 }
 
+// respondNoteError отправляет ответ об ошибке NoteService при поиске заметки.
+func respondNoteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNoteNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Заметка не найдена или не принадлежит вам"})
+	case errors.Is(err, service.ErrForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: "Недостаточно прав для этой операции с заметкой"})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске заметки"})
+	}
+}
+
+// parseNoteID разбирает ID заметки из параметра пути. При ошибке ответ уже отправлен.
+func parseNoteID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID заметки"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findNote загружает заметку по ID из параметра пути вместе с метками и проверяет, что у пользователя
+// есть доступ не ниже required. Без доступа заметка считается ненайденной (404), при недостаточном
+// уровне возвращается 403. При ошибке ответ уже отправлен.
+func (h *NoteHandler) findNote(c *gin.Context, userID uint, required string) (models.Note, bool) {
+	id, ok := parseNoteID(c)
+	if !ok {
+		return models.Note{}, false
+	}
+	note, err := h.notes.Get(c.Request.Context(), id, userID, required)
+	if err != nil {
+		respondNoteError(c, err)
+		return note, false
+	}
+	return note, true
+}
+
+// respondNotebookError отправляет ответ об ошибке проверки блокнота, в который помещается заметка.
+func respondNotebookError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrNotebookNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: errNotebookNotFound.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске блокнота"})
+}
+
+// noteSortColumns — поля, по которым можно сортировать список заметок.
+var noteSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+}
+
+// noteCursorValue возвращает значение поля сортировки заметки для курсора.
+func noteCursorValue(note models.Note, sort string) string {
+	switch sort {
+	case "created_at":
+		return note.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return note.UpdatedAt.Format(time.RFC3339Nano)
+	case "deleted_at":
+		return note.DeletedAt.Time.Format(time.RFC3339Nano)
+	default:
+		return note.Title
+	}
+}
+
+// notePage обрезает выборку до размера страницы и возвращает курсор следующей страницы (пустой, если её нет).
+func notePage(notes []models.Note, params listParams) ([]models.Note, string) {
+	if len(notes) <= params.Limit {
+		return notes, ""
+	}
+	notes = notes[:params.Limit]
+	last := notes[len(notes)-1]
+	return notes, encodeCursor(pageCursor{
+		Sort:  params.Sort,
+		Order: params.Order,
+		Value: noteCursorValue(last, params.Sort),
+		ID:    last.ID,
+	})
+}
+
 // GetNotes godoc
-// @Summary Получить все заметки
-// @Description Возвращает список всех заметок
+// @Summary Получить заметки
+// @Description Возвращает страницу заметок текущего пользователя. Следующая страница запрашивается по курсору из заголовка X-Next-Cursor (или ссылки в заголовке Link).
 // @Tags notes
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 20, не более 100)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Поле сортировки" Enums(created_at, updated_at, title) default(created_at)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(desc)
+// @Param created_after query string false "Созданы после (RFC 3339)"
+// @Param created_before query string false "Созданы до (RFC 3339)"
+// @Param updated_after query string false "Изменены после (RFC 3339)"
+// @Param updated_before query string false "Изменены до (RFC 3339)"
+// @Param tags query string false "Метки через запятую"
+// @Param tag_mode query string false "and — заметки со всеми метками, or — хотя бы с одной" Enums(and, or) default(or)
+// @Param notebook_id query string false "ID блокнота или root для заметок вне блокнотов"
+// @Param include_descendants query bool false "Учитывать заметки во вложенных блокнотах"
+// @Security ApiKeyAuth
 // @Success 200 {array} models.NoteSwagger
+// @Header 200 {integer} X-Total-Count "Общее количество заметок, подходящих под фильтры"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Header 200 {string} Link "Ссылка на следующую страницу (rel=next)"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /notes [get]
-func GetNotes(c *gin.Context) {
+func (h *NoteHandler) GetNotes(c *gin.Context) {
 	userId, ok := getUserIdFromContext(c)
 	if !ok {
 		return // Ошибка уже обработана в getUserIdFromContext
 	}
 
-	var notes []models.Note
-	db.DB.Where("user_id = ?", userId).Find(&notes)
+	params, err := parseListParams(c, noteSortColumns, "created_at")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query := withRequest(c, h.db).Model(&models.Note{}).Where("notes.user_id = ?", userId)
+	query, err = applyTimeFilters(c, query, "notes")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	query, err = applyTagFilter(c, withRequest(c, h.db), query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	query, err = applyNotebookFilter(c, withRequest(c, h.db), query, userId)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении заметок"})
+		return
+	}
+
+	notes := []models.Note{}
+	if err := paginate(query, params, noteSortColumns, "notes").Preload("Tags").Find(&notes).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении заметок"})
+		return
+	}
+
+	notes, nextCursor := notePage(notes, params)
+
+	setPaginationHeaders(c, total, nextCursor)
 	c.JSON(http.StatusOK, notes)
 }
 
+// SearchNotes godoc
+// @Summary Полнотекстовый поиск по заметкам
+// @Description Ищет заметки текущего пользователя по заголовку и содержимому, сортирует по релевантности и подсвечивает совпадения тегом <mark>
+// @Tags notes
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param limit query int false "Максимальное количество результатов (по умолчанию 20, не более 100)"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.NoteSearchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /notes/search [get]
+func (h *NoteHandler) SearchNotes(c *gin.Context) {
+	userId, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 100 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Параметр limit должен быть числом от 1 до 100"})
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := search.Notes(withRequest(c, h.db), userId, c.Query("q"), limit)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Параметр q обязателен"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске заметок"})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
 // @Summary Получить заметку по ID
-// @Description Возвращает заметку по её ID, если она принадлежит текущему пользователю
+// @Description Возвращает заметку по её ID, если она принадлежит текущему пользователю или открыта ему
 // @Tags notes
 // @Accept  json
 // @Produce  json
@@ -55,26 +247,13 @@ func GetNotes(c *gin.Context) {
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /notes/{id} [get]
 
-func GetNote(c *gin.Context) {
+func (h *NoteHandler) GetNote(c *gin.Context) {
 	userId, ok := getUserIdFromContext(c)
 	if !ok {
 		return // Ошибка уже обработана в getUserIdFromContext
 	}
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID заметки"})
-		return
-	}
-	var note models.Note
-	result := db.DB.Where("id = ? AND user_id = ?", uint(id), userId).First(&note)
-
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Заметка не найдена или не принадлежит вам"})
-			return
-		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске заметки"})
+	note, ok := h.findNote(c, userId, models.PermissionViewer)
+	if !ok {
 		return
 	}
 	c.JSON(http.StatusOK, note)
@@ -86,102 +265,155 @@ func GetNote(c *gin.Context) {
 // @Tags notes
 // @Accept json
 // @Produce json
-// @Param note body models.NoteSwagger true "Данные заметки"
-// @Success 200 {object} models.NoteSwagger
+// @Param note body models.NoteInput true "Данные заметки"
+// @Success 201 {object} models.NoteSwagger
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Блокнот не найден"
+// @Failure 500 {object} models.ErrorResponse
 // @Router /notes [post]
-func CreateNote(c *gin.Context) {
+func (h *NoteHandler) CreateNote(c *gin.Context) {
 	userID, ok := getUserIdFromContext(c)
 	if !ok {
 		return
 	}
-	var note models.Note
-	if err := c.ShouldBindJSON(&note); err != nil {
+	var input models.NoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
 		return
 	}
-	note.UserID = userID
-	db.DB.Create(&note)
+
+	note, err := h.notes.Create(c.Request.Context(), userID, input)
+	if errors.Is(err, service.ErrNotebookNotFound) {
+		respondNotebookError(c, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать заметку"})
+		return
+	}
 	c.JSON(http.StatusCreated, note)
 }
 
 // UpdateNote godoc
 // @Summary Обновить заметку
-// @Description Обновляет заметку по ID
+// @Description Обновляет заметку по ID. Доступно владельцу и пользователям с правом editor; метки задаются в пространстве меток владельца.
 // @Tags notes
 // @Accept json
 // @Produce json
 // @Param id path int true "ID заметки"
-// @Param note body models.Note true "Обновленные данные"
-// @Param note body models.NoteSwagger true "Обновленные данные"
+// @Param note body models.NoteInput true "Обновленные данные"
 // @Success 200 {object} models.NoteSwagger
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /notes/{id} [put]
-func UpdateNote(c *gin.Context) {
+func (h *NoteHandler) UpdateNote(c *gin.Context) {
     userID, ok := getUserIdFromContext(c)
     if !ok { return }
 
-    idStr := c.Param("id")
-    id, err := strconv.ParseUint(idStr, 10, 32)
-    if err != nil {
-        c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID заметки"})
-        return
-    }
-
-    var inputNote models.Note
+    var inputNote models.NoteInput
     if err := c.ShouldBindJSON(&inputNote); err != nil {
         c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
         return
     }
 
-    var existingNote models.Note
-    result := db.DB.Where("id = ? AND user_id = ?", uint(id), userID).First(&existingNote)
-    if result.Error != nil {
-        if result.Error == gorm.ErrRecordNotFound {
-            c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Заметка не найдена или не принадлежит вам"})
-            return
-        }
-        c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске заметки"})
+    existingNote, ok := h.findNote(c, userID, models.PermissionEditor)
+    if !ok { return }
+
+    existingNote, err := h.notes.Update(c.Request.Context(), existingNote, userID, inputNote)
+    if err != nil {
+        c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось обновить заметку"})
         return
     }
+    c.JSON(http.StatusOK, existingNote)
+}
 
-    existingNote.Title = inputNote.Title
-    existingNote.Content = inputNote.Content
+// MoveNote godoc
+// @Summary Переместить заметку
+// @Description Переносит заметку в другой блокнот или в корень (notebook_id = null)
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param input body models.MoveNoteInput true "Целевой блокнот"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.NoteSwagger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/move [post]
+func (h *NoteHandler) MoveNote(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionOwner)
+	if !ok {
+		return
+	}
 
-    db.DB.Save(&existingNote)
-    c.JSON(http.StatusOK, existingNote)
+	var input models.MoveNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+
+	note, err := h.notes.Move(c.Request.Context(), note, input.NotebookID)
+	if errors.Is(err, service.ErrNotebookNotFound) {
+		respondNotebookError(c, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось переместить заметку"})
+		return
+	}
+	c.JSON(http.StatusOK, note)
 }
 
 // DeleteNote godoc
 // @Summary Удалить заметку
-// @Description Удаляет заметку по ID
+// @Description Перемещает заметку в корзину. С permanent=true заметка (в том числе уже лежащая в корзине) удаляется безвозвратно вместе с историей правок. Удалять заметку может только владелец.
 // @Tags notes
 // @Produce json
 // @Param id path int true "ID заметки"
+// @Param permanent query bool false "Удалить безвозвратно"
 // @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /notes/{id} [delete]
-func DeleteNote(c *gin.Context) {
+func (h *NoteHandler) DeleteNote(c *gin.Context) {
     userID, ok := getUserIdFromContext(c)
     if !ok { return }
 
-    idStr := c.Param("id")
-    id, err := strconv.ParseUint(idStr, 10, 32)
+    id, ok := parseNoteID(c)
+    if !ok { return }
+
+    permanent, err := strconv.ParseBool(c.DefaultQuery("permanent", "false"))
     if err != nil {
-        c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID заметки"})
+        c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Параметр permanent должен быть true или false"})
         return
     }
 
-    result := db.DB.Where("id = ? AND user_id = ?", uint(id), userID).Delete(&models.Note{})
-
-    if result.Error != nil {
-        c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при удалении заметки"})
+    // Заметка ищется и в корзине, чтобы её можно было удалить безвозвратно
+    note, err := h.notes.GetWithTrashed(c.Request.Context(), id, userID, models.PermissionOwner)
+    if err != nil {
+        respondNoteError(c, err)
         return
     }
-    if result.RowsAffected == 0 {
+
+    err = h.notes.Delete(c.Request.Context(), note, permanent)
+    if errors.Is(err, service.ErrNoteNotFound) {
         c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Заметка не найдена или не принадлежит вам"})
         return
     }
+    if err != nil {
+        c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при удалении заметки"})
+        return
+    }
 
+    if permanent {
+        c.JSON(http.StatusOK, models.MessageResponse{Message: "Заметка удалена безвозвратно"})
+        return
+    }
     c.JSON(http.StatusOK, models.MessageResponse{Message: "Заметка успешно удалена"})
 }
-
@@ -7,8 +7,9 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
+
 	"github.com/gin-gonic/gin"
-	"github.com/heebit/notes-api/internal/controllers"
 	"github.com/heebit/notes-api/middleware"
 	"github.com/heebit/notes-api/models"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +20,8 @@ import (
 
 func TestNoteController(t *testing.T) {
 	// Настраиваем новую тестовую БД для этого тестового набора
-	testDB := setupTestDB()
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
 	// Откладываем закрытие подключения к БД до завершения всех тестов в этом файле
 	defer func() {
 		sqlDB, _ := testDB.DB()
@@ -29,12 +31,12 @@ func TestNoteController(t *testing.T) {
 	// Инициализируем роутер Gin для тестирования
 	r := gin.Default()
 	// Применяем ваш AuthMiddleware ко всем маршрутам заметок
-	r.Use(middleware.AuthMiddleware())
-	r.GET("/notes", controllers.GetNotes)
-	r.GET("/notes/:id", controllers.GetNote)
-	r.POST("/notes", controllers.CreateNote)
-	r.PUT("/notes/:id", controllers.UpdateNote)
-	r.DELETE("/notes/:id", controllers.DeleteNote)
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes", noteHandler.GetNotes)
+	r.GET("/notes/:id", noteHandler.GetNote)
+	r.POST("/notes", noteHandler.CreateNote)
+	r.PUT("/notes/:id", noteHandler.UpdateNote)
+	r.DELETE("/notes/:id", noteHandler.DeleteNote)
 
 	// Регистрируем двух тестовых пользователей и получаем их токены/ID
 	token, userID := registerAndLoginUser(t, testDB, "testuser_notes", "notes@example.com", "password123")
@@ -267,3 +269,227 @@ func TestNoteController(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "Неверный токен авторизации", "Сообщение об ошибке должно указывать на недействительный токен")
 	})
 }
+
+func TestSearchNotes(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes/search", noteHandler.SearchNotes)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_search", "search@example.com", "password123")
+	_, userID2 := registerAndLoginUser(t, testDB, "anotheruser_search", "another_search@example.com", "password123")
+
+	assert.NoError(t, testDB.Create(&models.Note{Title: "Рецепт борща", Content: "Свекла, капуста и немного укропа.", UserID: userID}).Error)
+	assert.NoError(t, testDB.Create(&models.Note{Title: "Список покупок", Content: "Купить свекла и хлеб.", UserID: userID}).Error)
+	assert.NoError(t, testDB.Create(&models.Note{Title: "Планы на неделю", Content: "Ничего интересного.", UserID: userID}).Error)
+	assert.NoError(t, testDB.Create(&models.Note{Title: "Свекла у соседа", Content: "Чужая заметка.", UserID: userID2}).Error)
+
+	t.Run("SearchNotes - Ranked results with highlights", func(t *testing.T) {
+		t.Log("Запуск: SearchNotes - Результаты отсортированы по релевантности и подсвечены")
+		req, _ := http.NewRequest(http.MethodGet, "/notes/search?q=свекла", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Ожидаем статус 200 OK")
+		var results []models.NoteSearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+
+		assert.Len(t, results, 2, "Должны быть найдены только заметки текущего пользователя")
+		for _, result := range results {
+			assert.Equal(t, userID, result.UserID, "Все найденные заметки должны принадлежать текущему пользователю")
+			assert.Contains(t, result.ContentSnippet, "<mark>", "Совпадение должно быть подсвечено")
+			assert.Greater(t, result.Rank, 0.0, "Ранг найденной заметки должен быть положительным")
+		}
+		assert.GreaterOrEqual(t, results[0].Rank, results[1].Rank, "Результаты должны быть отсортированы по убыванию ранга")
+	})
+
+	t.Run("SearchNotes - Title match ranks higher", func(t *testing.T) {
+		t.Log("Запуск: SearchNotes - Совпадение в заголовке важнее совпадения в тексте")
+		assert.NoError(t, testDB.Create(&models.Note{Title: "Капуста", Content: "Про огород.", UserID: userID}).Error)
+
+		req, _ := http.NewRequest(http.MethodGet, "/notes/search?q=капуста", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var results []models.NoteSearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		if assert.Len(t, results, 2) {
+			assert.Equal(t, "Капуста", results[0].Title, "Заметка с совпадением в заголовке должна быть первой")
+			assert.Contains(t, results[0].TitleSnippet, "<mark>Капуста</mark>")
+		}
+	})
+
+	t.Run("SearchNotes - Limit keeps best match", func(t *testing.T) {
+		t.Log("Запуск: SearchNotes - limit применяется после сортировки по релевантности")
+		req, _ := http.NewRequest(http.MethodGet, "/notes/search?q=капуста&limit=1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var results []models.NoteSearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "Капуста", results[0].Title)
+		}
+	})
+
+	t.Run("SearchNotes - Updated and deleted notes", func(t *testing.T) {
+		t.Log("Запуск: SearchNotes - Индекс учитывает изменения и удаление заметок")
+		var note models.Note
+		assert.NoError(t, testDB.Where("title = ?", "Планы на неделю").First(&note).Error)
+		note.Content = "Съездить за свекла на рынок."
+		assert.NoError(t, testDB.Save(&note).Error)
+		assert.NoError(t, testDB.Where("title = ?", "Рецепт борща").Delete(&models.Note{}).Error)
+
+		req, _ := http.NewRequest(http.MethodGet, "/notes/search?q=свекла&limit=10", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var results []models.NoteSearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		titles := []string{}
+		for _, result := range results {
+			titles = append(titles, result.Title)
+		}
+		assert.ElementsMatch(t, []string{"Список покупок", "Планы на неделю"}, titles)
+	})
+
+	t.Run("SearchNotes - Empty query", func(t *testing.T) {
+		t.Log("Запуск: SearchNotes - Пустой запрос")
+		req, _ := http.NewRequest(http.MethodGet, "/notes/search?q=%20", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Ожидаем статус 400 Bad Request")
+		assert.Contains(t, w.Body.String(), "Параметр q обязателен")
+	})
+
+	t.Run("SearchNotes - Invalid limit", func(t *testing.T) {
+		t.Log("Запуск: SearchNotes - Неверный limit")
+		req, _ := http.NewRequest(http.MethodGet, "/notes/search?q=свекла&limit=1000", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Ожидаем статус 400 Bad Request")
+	})
+}
+
+func TestGetNotesPagination(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes", noteHandler.GetNotes)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_pages", "pages@example.com", "password123")
+
+	// Пять заметок с разными датами создания: заметка 0 — самая старая
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	titles := []string{"Д", "Б", "Г", "А", "В"}
+	for i, title := range titles {
+		note := models.Note{Title: title, Content: "Контент", UserID: userID}
+		note.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		note.UpdatedAt = note.CreatedAt
+		assert.NoError(t, testDB.Create(&note).Error)
+	}
+
+	getPage := func(t *testing.T, url string) ([]models.Note, *httptest.ResponseRecorder) {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var notes []models.Note
+		if w.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		}
+		return notes, w
+	}
+
+	t.Run("GetNotes - Cursor pagination", func(t *testing.T) {
+		t.Log("Запуск: GetNotes - Постраничный обход по курсору")
+		collected := []string{}
+		url := "/notes?limit=2"
+		for pages := 0; url != ""; pages++ {
+			assert.Less(t, pages, 5, "Обход страниц должен завершиться")
+			notes, w := getPage(t, url)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "5", w.Header().Get("X-Total-Count"), "Заголовок должен содержать общее количество заметок")
+			for _, note := range notes {
+				collected = append(collected, note.Title)
+			}
+
+			url = ""
+			if cursor := w.Header().Get("X-Next-Cursor"); cursor != "" {
+				assert.Contains(t, w.Header().Get("Link"), `rel="next"`)
+				url = "/notes?limit=2&cursor=" + cursor
+			}
+		}
+		assert.Equal(t, []string{"В", "А", "Г", "Б", "Д"}, collected, "По умолчанию заметки отсортированы по дате создания по убыванию")
+	})
+
+	t.Run("GetNotes - Sort by title asc", func(t *testing.T) {
+		t.Log("Запуск: GetNotes - Сортировка по заголовку по возрастанию")
+		notes, w := getPage(t, "/notes?sort=title&order=asc&limit=3")
+		assert.Equal(t, http.StatusOK, w.Code)
+		if assert.Len(t, notes, 3) {
+			assert.Equal(t, "А", notes[0].Title)
+			assert.Equal(t, "В", notes[2].Title)
+		}
+
+		notes, w = getPage(t, "/notes?sort=title&order=asc&limit=3&cursor="+w.Header().Get("X-Next-Cursor"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		if assert.Len(t, notes, 2) {
+			assert.Equal(t, "Г", notes[0].Title)
+			assert.Equal(t, "Д", notes[1].Title)
+		}
+		assert.Empty(t, w.Header().Get("X-Next-Cursor"), "На последней странице курсора быть не должно")
+	})
+
+	t.Run("GetNotes - Date filters", func(t *testing.T) {
+		t.Log("Запуск: GetNotes - Фильтрация по дате создания")
+		after := base.Add(90 * time.Minute).Format(time.RFC3339)
+		before := base.Add(210 * time.Minute).Format(time.RFC3339)
+		notes, w := getPage(t, "/notes?created_after="+after+"&created_before="+before)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+		assert.Len(t, notes, 2)
+	})
+
+	t.Run("GetNotes - Invalid parameters", func(t *testing.T) {
+		t.Log("Запуск: GetNotes - Неверные параметры")
+		for _, url := range []string{
+			"/notes?limit=0",
+			"/notes?sort=content",
+			"/notes?order=up",
+			"/notes?cursor=garbage",
+			"/notes?created_after=yesterday",
+		} {
+			_, w := getPage(t, url)
+			assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		}
+
+		// Курсор, выданный для одной сортировки, нельзя применить к другой
+		_, w := getPage(t, "/notes?limit=1")
+		_, w = getPage(t, "/notes?limit=1&sort=title&cursor="+w.Header().Get("X-Next-Cursor"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
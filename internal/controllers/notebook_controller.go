@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// NotebookHandler обрабатывает запросы к блокнотам пользователя.
+type NotebookHandler struct {
+	db *gorm.DB
+}
+
+// NewNotebookHandler создает NotebookHandler, работающий с подключением tx.
+func NewNotebookHandler(tx *gorm.DB) *NotebookHandler {
+	return &NotebookHandler{db: tx}
+}
+
+// errNotebookNotFound возвращается, если блокнот не существует или принадлежит другому пользователю.
+var errNotebookNotFound = errors.New("Блокнот не найден")
+
+// findUserNotebook загружает блокнот текущего пользователя по ID из параметра пути.
+// При ошибке ответ уже отправлен.
+func (h *NotebookHandler) findUserNotebook(c *gin.Context, userID uint, idStr string) (models.Notebook, bool) {
+	var notebook models.Notebook
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID блокнота"})
+		return notebook, false
+	}
+	if err := withRequest(c, h.db).Where("id = ? AND user_id = ?", uint(id), userID).First(&notebook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: errNotebookNotFound.Error()})
+			return notebook, false
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске блокнота"})
+		return notebook, false
+	}
+	return notebook, true
+}
+
+// checkNotebookOwner проверяет, что блокнот существует и принадлежит пользователю. nil означает корень.
+func checkNotebookOwner(tx *gorm.DB, userID uint, notebookID *uint) error {
+	if notebookID == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Notebook{}).Where("id = ? AND user_id = ?", *notebookID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errNotebookNotFound
+	}
+	return nil
+}
+
+// userNotebooks возвращает все блокноты пользователя, отсортированные по имени.
+func userNotebooks(tx *gorm.DB, userID uint) ([]models.Notebook, error) {
+	notebooks := []models.Notebook{}
+	err := tx.Where("user_id = ?", userID).Order("name, id").Find(&notebooks).Error
+	return notebooks, err
+}
+
+// notebookSubtree возвращает ID блокнота и всех вложенных в него блокнотов.
+func notebookSubtree(notebooks []models.Notebook, rootID uint) []uint {
+	children := map[uint][]uint{}
+	for _, notebook := range notebooks {
+		if notebook.ParentID != nil {
+			children[*notebook.ParentID] = append(children[*notebook.ParentID], notebook.ID)
+		}
+	}
+
+	ids := []uint{rootID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// buildNotebookTree собирает дерево блокнотов, начиная с детей parentID (nil — корень).
+func buildNotebookTree(notebooks []models.Notebook, parentID *uint) []models.Notebook {
+	tree := []models.Notebook{}
+	for _, notebook := range notebooks {
+		if (parentID == nil && notebook.ParentID == nil) ||
+			(parentID != nil && notebook.ParentID != nil && *notebook.ParentID == *parentID) {
+			id := notebook.ID
+			notebook.Children = buildNotebookTree(notebooks, &id)
+			tree = append(tree, notebook)
+		}
+	}
+	return tree
+}
+
+// applyNotebookFilter оставляет заметки из блокнота notebook_id (root — заметки вне блокнотов).
+// С include_descendants=true учитываются и вложенные блокноты.
+func applyNotebookFilter(c *gin.Context, tx *gorm.DB, query *gorm.DB, userID uint) (*gorm.DB, error) {
+	notebookParam := c.Query("notebook_id")
+	if notebookParam == "" {
+		return query, nil
+	}
+	if notebookParam == "root" {
+		return query.Where("notes.notebook_id IS NULL"), nil
+	}
+
+	id, err := strconv.ParseUint(notebookParam, 10, 32)
+	if err != nil {
+		return query, errors.New("Параметр notebook_id должен быть числом или root")
+	}
+	includeDescendants, err := strconv.ParseBool(c.DefaultQuery("include_descendants", "false"))
+	if err != nil {
+		return query, errors.New("Параметр include_descendants должен быть true или false")
+	}
+	if !includeDescendants {
+		return query.Where("notes.notebook_id = ?", uint(id)), nil
+	}
+
+	notebooks, err := userNotebooks(tx, userID)
+	if err != nil {
+		return query, err
+	}
+	return query.Where("notes.notebook_id IN ?", notebookSubtree(notebooks, uint(id))), nil
+}
+
+// GetNotebooks godoc
+// @Summary Получить блокноты
+// @Description Возвращает дерево блокнотов текущего пользователя
+// @Tags notebooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Notebook
+// @Failure 500 {object} models.ErrorResponse
+// @Router /notebooks [get]
+func (h *NotebookHandler) GetNotebooks(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	notebooks, err := userNotebooks(withRequest(c, h.db), userID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении блокнотов"})
+		return
+	}
+	c.JSON(http.StatusOK, buildNotebookTree(notebooks, nil))
+}
+
+// GetNotebook godoc
+// @Summary Получить блокнот по ID
+// @Description Возвращает блокнот вместе с деревом вложенных блокнотов
+// @Tags notebooks
+// @Produce json
+// @Param id path int true "ID блокнота"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Notebook
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notebooks/{id} [get]
+func (h *NotebookHandler) GetNotebook(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	notebook, ok := h.findUserNotebook(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	notebooks, err := userNotebooks(withRequest(c, h.db), userID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении блокнотов"})
+		return
+	}
+	notebook.Children = buildNotebookTree(notebooks, &notebook.ID)
+	c.JSON(http.StatusOK, notebook)
+}
+
+// CreateNotebook godoc
+// @Summary Создать блокнот
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param notebook body models.NotebookInput true "Данные блокнота"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.Notebook
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Родительский блокнот не найден"
+// @Router /notebooks [post]
+func (h *NotebookHandler) CreateNotebook(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input models.NotebookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	if err := checkNotebookOwner(withRequest(c, h.db), userID, input.ParentID); err != nil {
+		if errors.Is(err, errNotebookNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Родительский блокнот не найден"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске блокнота"})
+		return
+	}
+
+	notebook := models.Notebook{Name: input.Name, UserID: userID, ParentID: input.ParentID}
+	if err := withRequest(c, h.db).Create(&notebook).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать блокнот"})
+		return
+	}
+	c.JSON(http.StatusCreated, notebook)
+}
+
+// RenameNotebook godoc
+// @Summary Переименовать блокнот
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path int true "ID блокнота"
+// @Param notebook body models.RenameNotebookInput true "Новое имя"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Notebook
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notebooks/{id} [put]
+func (h *NotebookHandler) RenameNotebook(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	notebook, ok := h.findUserNotebook(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input models.RenameNotebookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+
+	notebook.Name = input.Name
+	if err := withRequest(c, h.db).Save(&notebook).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось переименовать блокнот"})
+		return
+	}
+	c.JSON(http.StatusOK, notebook)
+}
+
+// MoveNotebook godoc
+// @Summary Переместить блокнот
+// @Description Переносит блокнот вместе со всеми вложенными блокнотами и заметками в другой блокнот или в корень (parent_id = null)
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path int true "ID блокнота"
+// @Param input body models.MoveNotebookInput true "Новый родитель"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Notebook
+// @Failure 400 {object} models.ErrorResponse "Блокнот нельзя переместить внутрь самого себя"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notebooks/{id}/move [post]
+func (h *NotebookHandler) MoveNotebook(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	notebook, ok := h.findUserNotebook(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input models.MoveNotebookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+
+	if input.ParentID != nil {
+		notebooks, err := userNotebooks(withRequest(c, h.db), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении блокнотов"})
+			return
+		}
+		found := false
+		for _, nb := range notebooks {
+			found = found || nb.ID == *input.ParentID
+		}
+		if !found {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Родительский блокнот не найден"})
+			return
+		}
+		for _, id := range notebookSubtree(notebooks, notebook.ID) {
+			if id == *input.ParentID {
+				c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Блокнот нельзя переместить внутрь самого себя"})
+				return
+			}
+		}
+	}
+
+	if err := withRequest(c, h.db).Model(&notebook).Update("parent_id", input.ParentID).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось переместить блокнот"})
+		return
+	}
+	notebook.ParentID = input.ParentID
+	c.JSON(http.StatusOK, notebook)
+}
+
+// DeleteNotebook godoc
+// @Summary Удалить блокнот
+// @Description mode=move (по умолчанию) переносит заметки и вложенные блокноты в корень, mode=delete удаляет блокнот вместе со всеми вложенными блокнотами и заметками
+// @Tags notebooks
+// @Produce json
+// @Param id path int true "ID блокнота"
+// @Param mode query string false "Что делать с содержимым" Enums(move, delete) default(move)
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notebooks/{id} [delete]
+func (h *NotebookHandler) DeleteNotebook(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	notebook, ok := h.findUserNotebook(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var err error
+	switch c.DefaultQuery("mode", "move") {
+	case "move":
+		err = withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+			// Заметки в корзине тоже отвязываются, чтобы после восстановления они оказались в корне
+			if err := tx.Unscoped().Model(&models.Note{}).Where("notebook_id = ?", notebook.ID).Update("notebook_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Notebook{}).Where("parent_id = ?", notebook.ID).Update("parent_id", nil).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&notebook).Error
+		})
+	case "delete":
+		notebooks, loadErr := userNotebooks(withRequest(c, h.db), userID)
+		if loadErr != nil {
+			err = loadErr
+			break
+		}
+		ids := notebookSubtree(notebooks, notebook.ID)
+		err = withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("notebook_id IN ?", ids).Delete(&models.Note{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", ids).Delete(&models.Notebook{}).Error
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Параметр mode должен быть move или delete"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при удалении блокнота"})
+		return
+	}
+	c.JSON(http.StatusOK, models.MessageResponse{Message: "Блокнот успешно удален"})
+}
@@ -0,0 +1,171 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotebookController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	notebookHandler := controllers.NewNotebookHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes", noteHandler.GetNotes)
+	r.POST("/notes", noteHandler.CreateNote)
+	r.POST("/notes/:id/move", noteHandler.MoveNote)
+	r.GET("/notebooks", notebookHandler.GetNotebooks)
+	r.GET("/notebooks/:id", notebookHandler.GetNotebook)
+	r.POST("/notebooks", notebookHandler.CreateNotebook)
+	r.PUT("/notebooks/:id", notebookHandler.RenameNotebook)
+	r.POST("/notebooks/:id/move", notebookHandler.MoveNotebook)
+	r.DELETE("/notebooks/:id", notebookHandler.DeleteNotebook)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_notebooks", "notebooks@example.com", "password123")
+	token2, _ := registerAndLoginUser(t, testDB, "anotheruser_notebooks", "another_notebooks@example.com", "password123")
+
+	idPath := func(prefix string, id uint) string {
+		return prefix + strconv.FormatUint(uint64(id), 10)
+	}
+	createNotebook := func(t *testing.T, name string, parentID *uint) models.Notebook {
+		w := doRequest(r, http.MethodPost, "/notebooks", token, models.NotebookInput{Name: name, ParentID: parentID})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var notebook models.Notebook
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notebook))
+		return notebook
+	}
+	createNote := func(t *testing.T, title string, notebookID *uint) models.Note {
+		w := doRequest(r, http.MethodPost, "/notes", token, models.NoteInput{Title: title, Content: "Контент", NotebookID: notebookID})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var note models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+		return note
+	}
+	listNotes := func(t *testing.T, query string) []string {
+		w := doRequest(r, http.MethodGet, "/notes?"+query, token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var notes []models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		titles := []string{}
+		for _, note := range notes {
+			titles = append(titles, note.Title)
+		}
+		return titles
+	}
+
+	// Структура: Работа -> Проекты -> Архив, Личное
+	work := createNotebook(t, "Работа", nil)
+	projects := createNotebook(t, "Проекты", &work.ID)
+	archive := createNotebook(t, "Архив", &projects.ID)
+	personal := createNotebook(t, "Личное", nil)
+
+	createNote(t, "План", &work.ID)
+	spec := createNote(t, "ТЗ", &projects.ID)
+	createNote(t, "Старое", &archive.ID)
+	createNote(t, "Без блокнота", nil)
+
+	t.Run("GetNotebooks - Tree", func(t *testing.T) {
+		t.Log("Запуск: GetNotebooks - Дерево блокнотов")
+		w := doRequest(r, http.MethodGet, "/notebooks", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var tree []models.Notebook
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tree))
+		if assert.Len(t, tree, 2) {
+			assert.Equal(t, "Личное", tree[0].Name)
+			assert.Equal(t, "Работа", tree[1].Name)
+			if assert.Len(t, tree[1].Children, 1) {
+				assert.Equal(t, "Проекты", tree[1].Children[0].Name)
+				assert.Len(t, tree[1].Children[0].Children, 1)
+			}
+		}
+
+		w = doRequest(r, http.MethodGet, idPath("/notebooks/", work.ID), token2, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, "Чужой блокнот не должен быть доступен")
+	})
+
+	t.Run("CreateNote - Foreign notebook", func(t *testing.T) {
+		t.Log("Запуск: CreateNote - Заметка в чужом блокноте")
+		w := doRequest(r, http.MethodPost, "/notes", token2, models.NoteInput{Title: "Чужая", Content: "Контент", NotebookID: &work.ID})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GetNotes - Filter by notebook", func(t *testing.T) {
+		t.Log("Запуск: GetNotes - Фильтрация по блокноту")
+		assert.ElementsMatch(t, []string{"План"}, listNotes(t, "notebook_id="+strconv.FormatUint(uint64(work.ID), 10)))
+		assert.ElementsMatch(t, []string{"План", "ТЗ", "Старое"},
+			listNotes(t, "include_descendants=true&notebook_id="+strconv.FormatUint(uint64(work.ID), 10)))
+		assert.ElementsMatch(t, []string{"Без блокнота"}, listNotes(t, "notebook_id=root"))
+
+		w := doRequest(r, http.MethodGet, "/notes?include_descendants=yes&notebook_id="+strconv.FormatUint(uint64(work.ID), 10), token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Значение include_descendants проверяется")
+	})
+
+	t.Run("MoveNote - Successful", func(t *testing.T) {
+		t.Log("Запуск: MoveNote - Перенос заметки")
+		w := doRequest(r, http.MethodPost, idPath("/notes/", spec.ID)+"/move", token, models.MoveNoteInput{NotebookID: &personal.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var note models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+		if assert.NotNil(t, note.NotebookID) {
+			assert.Equal(t, personal.ID, *note.NotebookID)
+		}
+
+		w = doRequest(r, http.MethodPost, idPath("/notes/", spec.ID)+"/move", token, models.MoveNoteInput{})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []string{"Без блокнота", "ТЗ"}, listNotes(t, "notebook_id=root"))
+	})
+
+	t.Run("MoveNotebook - Subtree and cycles", func(t *testing.T) {
+		t.Log("Запуск: MoveNotebook - Перенос поддерева и защита от циклов")
+		w := doRequest(r, http.MethodPost, idPath("/notebooks/", work.ID)+"/move", token, models.MoveNotebookInput{ParentID: &archive.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Блокнот нельзя переместить в собственного потомка")
+
+		w = doRequest(r, http.MethodPost, idPath("/notebooks/", projects.ID)+"/move", token, models.MoveNotebookInput{ParentID: &personal.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []string{"Старое"},
+			listNotes(t, "include_descendants=true&notebook_id="+strconv.FormatUint(uint64(personal.ID), 10)),
+			"Вложенные блокноты переезжают вместе с родителем")
+
+		w = doRequest(r, http.MethodPut, idPath("/notebooks/", projects.ID), token, models.RenameNotebookInput{Name: "Проекты 2025"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Проекты 2025")
+	})
+
+	t.Run("DeleteNotebook - Move to root", func(t *testing.T) {
+		t.Log("Запуск: DeleteNotebook - Перенос содержимого в корень")
+		w := doRequest(r, http.MethodDelete, idPath("/notebooks/", projects.ID), token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var moved models.Notebook
+		assert.NoError(t, testDB.First(&moved, archive.ID).Error)
+		assert.Nil(t, moved.ParentID, "Вложенный блокнот должен оказаться в корне")
+	})
+
+	t.Run("DeleteNotebook - Cascade delete", func(t *testing.T) {
+		t.Log("Запуск: DeleteNotebook - Удаление вместе с содержимым")
+		createNotebook(t, "Вложенный", &work.ID)
+		w := doRequest(r, http.MethodDelete, idPath("/notebooks/", work.ID)+"?mode=delete", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		testDB.Model(&models.Notebook{}).Where("user_id = ? AND name IN ?", userID, []string{"Работа", "Вложенный"}).Count(&count)
+		assert.Zero(t, count, "Блокнот и вложенные блокноты должны быть удалены")
+		assert.NotContains(t, listNotes(t, ""), "План", "Заметки удаленного блокнота должны быть удалены")
+
+		w = doRequest(r, http.MethodDelete, idPath("/notebooks/", personal.ID)+"?mode=purge", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
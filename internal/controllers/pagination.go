@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageCursor — содержимое непрозрачного курсора: значение поля сортировки и ID последней записи страницы.
+// Поля сортировки и направления сохраняются, чтобы курсор нельзя было применить к другому порядку.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(raw, &cursor)
+	return cursor, err
+}
+
+// listParams — параметры постраничного списка: размер страницы, сортировка и курсор.
+type listParams struct {
+	Limit  int
+	Sort   string
+	Order  string
+	Cursor *pageCursor
+}
+
+// parseListParams разбирает limit, sort, order и cursor из строки запроса.
+// sortColumns задает допустимые поля сортировки; ошибки содержат текст для ответа клиенту.
+func parseListParams(c *gin.Context, sortColumns map[string]string, defaultSort string) (listParams, error) {
+	params := listParams{Limit: defaultPageLimit, Sort: defaultSort, Order: "desc"}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return params, fmt.Errorf("Параметр limit должен быть числом от 1 до %d", maxPageLimit)
+		}
+		params.Limit = limit
+	}
+	if sort := c.Query("sort"); sort != "" {
+		if _, ok := sortColumns[sort]; !ok {
+			return params, errors.New("Недопустимое поле сортировки: " + sort)
+		}
+		params.Sort = sort
+	}
+	if order := c.Query("order"); order != "" {
+		if order != "asc" && order != "desc" {
+			return params, errors.New("Параметр order должен быть asc или desc")
+		}
+		params.Order = order
+	}
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := decodeCursor(cursorStr)
+		if err != nil || cursor.Sort != params.Sort || cursor.Order != params.Order {
+			return params, errors.New("Неверный курсор")
+		}
+		if _, err := cursorValue(cursor); err != nil {
+			return params, errors.New("Неверный курсор")
+		}
+		params.Cursor = &cursor
+	}
+	return params, nil
+}
+
+// cursorValue приводит значение из курсора к типу поля сортировки: поля *_at хранят время.
+func cursorValue(cursor pageCursor) (interface{}, error) {
+	if strings.HasSuffix(cursor.Sort, "_at") {
+		return time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	return cursor.Value, nil
+}
+
+// applyTimeFilters добавляет фильтры created_after/created_before/updated_after/updated_before (RFC 3339).
+func applyTimeFilters(c *gin.Context, query *gorm.DB, table string) (*gorm.DB, error) {
+	filters := []struct {
+		param, column, op string
+	}{
+		{"created_after", "created_at", ">"},
+		{"created_before", "created_at", "<"},
+		{"updated_after", "updated_at", ">"},
+		{"updated_before", "updated_at", "<"},
+	}
+	for _, f := range filters {
+		value := c.Query(f.param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("Параметр %s должен быть датой в формате RFC 3339", f.param)
+		}
+		query = query.Where(fmt.Sprintf("%s.%s %s ?", table, f.column, f.op), t)
+	}
+	return query, nil
+}
+
+// paginate применяет к запросу курсор, сортировку и лимит.
+// Выбирается на одну запись больше лимита, чтобы понять, есть ли следующая страница.
+func paginate(query *gorm.DB, params listParams, sortColumns map[string]string, table string) *gorm.DB {
+	column := table + "." + sortColumns[params.Sort]
+	idColumn := table + ".id"
+	op := "<"
+	if params.Order == "asc" {
+		op = ">"
+	}
+
+	if params.Cursor != nil {
+		value, _ := cursorValue(*params.Cursor)
+		query = query.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", column, op, column, idColumn, op),
+			value, value, params.Cursor.ID,
+		)
+	}
+	return query.
+		Order(fmt.Sprintf("%s %s, %s %s", column, params.Order, idColumn, params.Order)).
+		Limit(params.Limit + 1)
+}
+
+// setPaginationHeaders выставляет X-Total-Count, а при наличии следующей страницы — X-Next-Cursor и Link.
+func setPaginationHeaders(c *gin.Context, total int64, nextCursor string) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	if nextCursor == "" {
+		return
+	}
+	c.Header("X-Next-Cursor", nextCursor)
+
+	next := url.URL{Path: c.Request.URL.Path}
+	query := c.Request.URL.Query()
+	query.Set("cursor", nextCursor)
+	next.RawQuery = query.Encode()
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// sendPasswordResetEmail выпускает токен сброса пароля и отправляет пользователю письмо со ссылкой
+// на страницу cfg.App.PasswordResetURL, а если она не задана — с самим токеном.
+func sendPasswordResetEmail(ctx context.Context, tx *gorm.DB, mail mailer.Mailer, cfg *config.Config, user models.User) error {
+	token, err := auth.CreatePasswordReset(tx, user.ID, cfg.Auth.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	instructions := fmt.Sprintf("Чтобы задать новый пароль, отправьте токен вместе с новым паролем в запросе POST %s/auth/password/reset.\nТокен: %s",
+		cfg.App.BaseURL, token)
+	if cfg.App.PasswordResetURL != "" {
+		link, err := url.Parse(cfg.App.PasswordResetURL)
+		if err != nil {
+			return err
+		}
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+		instructions = "Чтобы задать новый пароль, перейдите по ссылке:\n" + link.String()
+	}
+	return mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n%s\n\n"+
+			"Срок действия — %s, использовать можно один раз. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+			user.Username, instructions, cfg.Auth.PasswordResetTTL),
+	})
+}
+
+// ForgotPassword godoc
+// @Summary Запрос сброса пароля
+// @Description Отправляет на email одноразовую ссылку для сброса пароля. Ответ одинаков независимо от того, зарегистрирован ли email.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param input body models.ForgotPasswordInput true "Email пользователя"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input models.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+	const message = "Если email зарегистрирован, на него отправлена ссылка для сброса пароля"
+
+	// Поиск пользователя и отправка письма идут в фоне, чтобы время ответа не выдавало,
+	// зарегистрирован ли email
+	ctx := context.WithoutCancel(c.Request.Context())
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		h.sendPasswordReset(ctx, input.Email)
+	}()
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// sendPasswordReset отправляет письмо для сброса пароля пользователю с адресом email, если он есть.
+// Ошибки только записываются в журнал: клиент уже получил ответ.
+func (h *AuthHandler) sendPasswordReset(ctx context.Context, email string) {
+	var user models.User
+	if err := h.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.ErrorContext(ctx, "Ошибка при поиске пользователя для сброса пароля", "error", err)
+		}
+		return
+	}
+	if err := sendPasswordResetEmail(ctx, h.db.WithContext(ctx), h.mail, h.cfg, user); err != nil {
+		slog.ErrorContext(ctx, "Не удалось отправить письмо для сброса пароля", "recipient_id", user.ID, "error", err)
+	}
+}
+
+// ResetPassword godoc
+// @Summary Сброс пароля
+// @Description Задает новый пароль по токену из письма. Токен одноразовый; после сброса все сессии пользователя завершаются.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param input body models.ResetPasswordInput true "Токен и новый пароль"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ввод"})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword, bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось захешировать пароль"})
+		return
+	}
+
+	err = withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		userID, err := auth.ConsumePasswordReset(tx, input.Token)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return auth.RevokeAllForUser(tx, userID)
+	})
+	if errors.Is(err, auth.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ссылка для сброса пароля недействительна или устарела"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сбросить пароль"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Пароль успешно изменен"})
+}
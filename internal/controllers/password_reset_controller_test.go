@@ -0,0 +1,138 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordReset(t *testing.T) {
+	testDB := setupTestDB(t)
+	authHandler := newAuthHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.POST("/auth/login", authHandler.Login)
+	r.POST("/auth/refresh", authHandler.Refresh)
+	r.POST("/auth/password/forgot", authHandler.ForgotPassword)
+	r.POST("/auth/password/reset", authHandler.ResetPassword)
+
+	_, userID := registerAndLoginUser(t, testDB, "testuser_reset", "reset@example.com", "password123")
+
+	tokenPattern := regexp.MustCompile(`Токен: (\S+)`)
+	requestReset := func(t *testing.T) string {
+		w := doRequest(r, http.MethodPost, "/auth/password/forgot", "", models.ForgotPasswordInput{Email: "reset@example.com"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		authHandler.Wait()
+
+		var email models.OutboxEmail
+		assert.NoError(t, testDB.Where("\"to\" = ?", "reset@example.com").Order("id DESC").First(&email).Error)
+		match := tokenPattern.FindStringSubmatch(email.Body)
+		if !assert.Len(t, match, 2, "Письмо должно содержать токен") {
+			return ""
+		}
+		return match[1]
+	}
+
+	t.Run("ForgotPassword - Unknown email", func(t *testing.T) {
+		t.Log("Запуск: ForgotPassword - Ответ не раскрывает наличие email")
+		known := doRequest(r, http.MethodPost, "/auth/password/forgot", "", models.ForgotPasswordInput{Email: "reset@example.com"})
+		unknown := doRequest(r, http.MethodPost, "/auth/password/forgot", "", models.ForgotPasswordInput{Email: "nobody@example.com"})
+		assert.Equal(t, http.StatusOK, unknown.Code)
+		assert.Equal(t, known.Body.String(), unknown.Body.String())
+		authHandler.Wait()
+
+		var count int64
+		testDB.Model(&models.OutboxEmail{}).Where("\"to\" = ?", "nobody@example.com").Count(&count)
+		assert.Zero(t, count)
+
+		w := doRequest(r, http.MethodPost, "/auth/password/forgot", "", models.ForgotPasswordInput{Email: "not-an-email"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ForgotPassword - Reset page link", func(t *testing.T) {
+		t.Log("Запуск: ForgotPassword - Ссылка ведет на страницу APP_PASSWORD_RESET_URL")
+		cfg := testConfig()
+		cfg.App.PasswordResetURL = "https://app.example.com/reset?lang=ru"
+		handler := controllers.NewAuthHandler(testDB, mailer.NewOutbox(testDB), cfg)
+		router := gin.New()
+		router.POST("/auth/password/forgot", handler.ForgotPassword)
+
+		w := doRequest(router, http.MethodPost, "/auth/password/forgot", "", models.ForgotPasswordInput{Email: "reset@example.com"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		handler.Wait()
+
+		var email models.OutboxEmail
+		assert.NoError(t, testDB.Where("\"to\" = ?", "reset@example.com").Order("id DESC").First(&email).Error)
+		match := regexp.MustCompile(`https://app\.example\.com/reset\?\S+`).FindString(email.Body)
+		link, err := url.Parse(match)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "ru", link.Query().Get("lang"))
+			assert.NotEmpty(t, link.Query().Get("token"))
+		}
+		assert.NotContains(t, email.Body, "/reset-password", "Ссылка не ведет на несуществующий маршрут API")
+	})
+
+	t.Run("ResetPassword - Successful and single-use", func(t *testing.T) {
+		t.Log("Запуск: ResetPassword - Сброс пароля по ссылке из письма")
+		w := doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "testuser_reset", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var session models.TokenPair
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+
+		token := requestReset(t)
+		var stored models.PasswordResetToken
+		assert.NoError(t, testDB.Where("user_id = ?", userID).Order("id DESC").First(&stored).Error)
+		assert.Equal(t, utils.HashToken(token), stored.TokenHash, "В базе хранится только хеш токена")
+
+		w = doRequest(r, http.MethodPost, "/auth/password/reset", "", models.ResetPasswordInput{Token: token, NewPassword: "newpassword456"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var user models.User
+		assert.NoError(t, testDB.First(&user, userID).Error)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("newpassword456")))
+
+		w = doRequest(r, http.MethodPost, "/auth/password/reset", "", models.ResetPasswordInput{Token: token, NewPassword: "another789"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Токен действует один раз")
+
+		w = doRequest(r, http.MethodPost, "/auth/refresh", "", models.RefreshInput{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "После сброса пароля сессии завершаются")
+	})
+
+	t.Run("ResetPassword - Only latest token is valid", func(t *testing.T) {
+		t.Log("Запуск: ResetPassword - Новый запрос аннулирует прежнюю ссылку")
+		first := requestReset(t)
+		second := requestReset(t)
+		w := doRequest(r, http.MethodPost, "/auth/password/reset", "", models.ResetPasswordInput{Token: first, NewPassword: "password123"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doRequest(r, http.MethodPost, "/auth/password/reset", "", models.ResetPasswordInput{Token: second, NewPassword: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ResetPassword - Expired token", func(t *testing.T) {
+		t.Log("Запуск: ResetPassword - Истекший токен")
+		token := requestReset(t)
+		testDB.Model(&models.PasswordResetToken{}).Where("token_hash = ?", utils.HashToken(token)).
+			Update("expires_at", time.Now().Add(-time.Minute))
+		w := doRequest(r, http.MethodPost, "/auth/password/reset", "", models.ResetPasswordInput{Token: token, NewPassword: "password123"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "недействительна или устарела")
+
+		w = doRequest(r, http.MethodPost, "/auth/password/reset", "", models.ResetPasswordInput{Token: token, NewPassword: "123"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,149 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/ratelimit"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	limit := ratelimit.Limit{Requests: 3, Per: time.Minute}
+	cfg := testConfig().RateLimit
+	cfg.Limits["test_public"] = config.Rate{Requests: 2, Per: time.Hour}
+
+	newRouter := func(cfg config.RateLimitConfig, store ratelimit.Store) *gin.Engine {
+		limiter := middleware.NewRateLimiter(cfg, store)
+		r, err := routes.NewEngine(testConfig().Server)
+		assert.NoError(t, err)
+		r.GET("/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), limiter.RateLimit("test_notes", limit), noteHandler.GetNotes)
+		r.GET("/public/:token", limiter.RateLimit("test_public", limit), noteHandler.GetPublicNote)
+		return r
+	}
+	r := newRouter(cfg, ratelimit.NewMemoryStore())
+
+	doRequestFrom := func(url, token, ip string) *httptest.ResponseRecorder {
+		req := newRequest(http.MethodGet, url, token, nil)
+		req.RemoteAddr = ip + ":40000"
+		return serve(r, req)
+	}
+
+	stores := map[string]ratelimit.Store{
+		"memory":   ratelimit.NewMemoryStore(),
+		"database": ratelimit.NewDBStore(testDB),
+	}
+	for name, store := range stores {
+		r = newRouter(cfg, store)
+		token, _ := registerAndLoginUser(t, testDB, "testuser_ratelimit_"+name, "ratelimit_"+name+"@example.com", "password123")
+		token2, _ := registerAndLoginUser(t, testDB, "anotheruser_ratelimit_"+name, "another_ratelimit_"+name+"@example.com", "password123")
+
+		t.Run("RateLimit - Per user ("+name+")", func(t *testing.T) {
+			t.Log("Запуск: RateLimit - Ограничение запросов пользователя")
+			for i := 0; i < 3; i++ {
+				w := doRequestFrom("/notes", token, "192.0.2.10")
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+				assert.Equal(t, []string{"2", "1", "0"}[i], w.Header().Get("X-RateLimit-Remaining"))
+				assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+			}
+
+			w := doRequestFrom("/notes", token, "192.0.2.11")
+			assert.Equal(t, http.StatusTooManyRequests, w.Code, "Лимит пользователя не зависит от адреса")
+			assert.Equal(t, "20", w.Header().Get("Retry-After"), "Токен пополняется за минуту / 3")
+
+			assert.Equal(t, http.StatusOK, doRequestFrom("/notes", token2, "192.0.2.10").Code, "У другого пользователя своя корзина")
+		})
+
+		t.Run("RateLimit - Per IP with config override ("+name+")", func(t *testing.T) {
+			t.Log("Запуск: RateLimit - Ограничение запросов без авторизации по IP")
+			ip := "198.51.100.20"
+			if name == "database" {
+				ip = "198.51.100.21"
+			}
+			for i := 0; i < 2; i++ {
+				w := doRequestFrom("/public/unknown", "", ip)
+				assert.Equal(t, http.StatusNotFound, w.Code)
+				assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"), "Лимит задан RATE_LIMIT_TEST_PUBLIC")
+			}
+			assert.Equal(t, http.StatusTooManyRequests, doRequestFrom("/public/unknown", "", ip).Code)
+			assert.Equal(t, http.StatusNotFound, doRequestFrom("/public/unknown", "", "203.0.113.20").Code)
+		})
+	}
+
+	t.Run("DBStore - Refill and cleanup", func(t *testing.T) {
+		t.Log("Запуск: DBStore - Пополнение корзины и очистка")
+		store := ratelimit.NewDBStore(testDB)
+		now := time.Now()
+		for i := 0; i < 3; i++ {
+			result, err := store.Take(t.Context(), "refill", limit, now)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+		result, err := store.Take(t.Context(), "refill", limit, now)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		result, err = store.Take(t.Context(), "refill", limit, now.Add(20*time.Second))
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed, "Через Per/Requests появляется новый токен")
+
+		assert.NoError(t, store.Cleanup(t.Context(), now.Add(2*time.Minute)))
+		var count int64
+		testDB.Model(&models.RateLimitBucket{}).Where("key = ?", "refill").Count(&count)
+		assert.Zero(t, count, "Заполнившиеся корзины удаляются")
+	})
+
+	t.Run("RateLimit - Spoofed X-Forwarded-For", func(t *testing.T) {
+		t.Log("Запуск: RateLimit - Подмена X-Forwarded-For не сбрасывает лимит")
+		r = newRouter(cfg, ratelimit.NewMemoryStore())
+		doForwarded := func(remoteIP, forwardedFor string) *httptest.ResponseRecorder {
+			req := newRequest(http.MethodGet, "/public/unknown", "", nil)
+			req.RemoteAddr = remoteIP + ":40000"
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			return serve(r, req)
+		}
+
+		assert.Equal(t, http.StatusNotFound, doForwarded("198.51.100.30", "203.0.113.1").Code)
+		assert.Equal(t, http.StatusNotFound, doForwarded("198.51.100.30", "203.0.113.2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, doForwarded("198.51.100.30", "203.0.113.3").Code,
+			"Без доверенных прокси IP клиента — адрес соединения")
+
+		server := testConfig().Server
+		server.TrustedProxies = []string{"10.0.0.0/8"}
+		proxied, err := routes.NewEngine(server)
+		assert.NoError(t, err)
+		proxied.GET("/public/:token", middleware.NewRateLimiter(cfg, ratelimit.NewMemoryStore()).RateLimit("test_public", limit), noteHandler.GetPublicNote)
+		r = proxied
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, http.StatusNotFound, doForwarded("10.0.0.5", "203.0.113.10").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, doForwarded("10.0.0.5", "203.0.113.10").Code)
+		assert.Equal(t, http.StatusNotFound, doForwarded("10.0.0.5", "203.0.113.11").Code,
+			"За доверенным прокси клиенты различаются по X-Forwarded-For")
+	})
+
+	t.Run("RateLimit - Disabled", func(t *testing.T) {
+		t.Log("Запуск: RateLimit - Отключение через RATE_LIMIT_ENABLED")
+		disabled := cfg
+		disabled.Enabled = false
+		r = newRouter(disabled, ratelimit.NewMemoryStore())
+		w := doRequestFrom("/public/unknown", "", "198.51.100.20")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	})
+}
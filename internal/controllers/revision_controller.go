@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/service"
+	"github.com/heebit/notes-api/models"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// findRevision загружает ревизию заметки по номеру. При ошибке ответ уже отправлен.
+func (h *NoteHandler) findRevision(c *gin.Context, note models.Note, revStr string) (models.NoteRevision, bool) {
+	rev, err := strconv.Atoi(revStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат номера ревизии"})
+		return models.NoteRevision{}, false
+	}
+	revision, err := h.notes.Revision(c.Request.Context(), note, rev)
+	if err != nil {
+		if errors.Is(err, service.ErrRevisionNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Ревизия не найдена"})
+			return revision, false
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске ревизии"})
+		return revision, false
+	}
+	return revision, true
+}
+
+// revisionText представляет ревизию как документ для сравнения: заголовок, пустая строка, содержимое.
+func revisionText(revision models.NoteRevision) string {
+	return revision.Title + "\n\n" + revision.Content + "\n"
+}
+
+// GetNoteRevisions godoc
+// @Summary История правок заметки
+// @Description Возвращает все ревизии заметки, начиная с последней
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.NoteRevision
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/revisions [get]
+func (h *NoteHandler) GetNoteRevisions(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionViewer)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.notes.Revisions(c.Request.Context(), note)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении ревизий"})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetNoteRevision godoc
+// @Summary Получить ревизию заметки
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param rev path int true "Номер ревизии"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.NoteRevision
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/revisions/{rev} [get]
+func (h *NoteHandler) GetNoteRevision(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionViewer)
+	if !ok {
+		return
+	}
+	revision, ok := h.findRevision(c, note, c.Param("rev"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffNoteRevisions godoc
+// @Summary Сравнить ревизии заметки
+// @Description Возвращает unified diff между двумя ревизиями. Первая строка документа — заголовок, затем содержимое.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param from query int true "Исходная ревизия"
+// @Param to query int true "Конечная ревизия"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RevisionDiff
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/revisions/diff [get]
+func (h *NoteHandler) DiffNoteRevisions(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionViewer)
+	if !ok {
+		return
+	}
+	from, ok := h.findRevision(c, note, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.findRevision(c, note, c.Query("to"))
+	if !ok {
+		return
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(revisionText(from)),
+		B:        difflib.SplitLines(revisionText(to)),
+		FromFile: fmt.Sprintf("revision %d", from.Revision),
+		ToFile:   fmt.Sprintf("revision %d", to.Revision),
+		Context:  3,
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при сравнении ревизий"})
+		return
+	}
+	c.JSON(http.StatusOK, models.RevisionDiff{NoteID: note.ID, From: from.Revision, To: to.Revision, Diff: diff})
+}
+
+// RestoreNoteRevision godoc
+// @Summary Восстановить ревизию заметки
+// @Description Возвращает заметке заголовок и содержимое выбранной ревизии. Восстановление сохраняется как новая ревизия, история не переписывается. Требуется право editor.
+// @Tags revisions
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param rev path int true "Номер ревизии"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.NoteSwagger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/revisions/{rev}/restore [post]
+func (h *NoteHandler) RestoreNoteRevision(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionEditor)
+	if !ok {
+		return
+	}
+	revision, ok := h.findRevision(c, note, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	note, err := h.notes.RestoreRevision(c.Request.Context(), note, userID, revision)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось восстановить ревизию"})
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
@@ -0,0 +1,167 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisionController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.POST("/notes", noteHandler.CreateNote)
+	r.PUT("/notes/:id", noteHandler.UpdateNote)
+	r.GET("/notes/:id/revisions", noteHandler.GetNoteRevisions)
+	r.GET("/notes/:id/revisions/diff", noteHandler.DiffNoteRevisions)
+	r.GET("/notes/:id/revisions/:rev", noteHandler.GetNoteRevision)
+	r.POST("/notes/:id/revisions/:rev/restore", noteHandler.RestoreNoteRevision)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_revisions", "revisions@example.com", "password123")
+	token2, _ := registerAndLoginUser(t, testDB, "anotheruser_revisions", "another_revisions@example.com", "password123")
+
+	w := doRequest(r, http.MethodPost, "/notes", token, models.NoteInput{Title: "Черновик", Content: "строка 1\nстрока 2\nстрока 3"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var note models.Note
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+	notePath := "/notes/" + strconv.FormatUint(uint64(note.ID), 10)
+
+	t.Run("UpdateNote - Records revisions", func(t *testing.T) {
+		t.Log("Запуск: UpdateNote - Каждое изменение сохраняется как ревизия")
+		w := doRequest(r, http.MethodPut, notePath, token, models.NoteInput{Title: "Черновик", Content: "строка 1\nстрока 2 исправлена\nстрока 3"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doRequest(r, http.MethodPut, notePath, token, models.NoteInput{Title: "Чистовик", Content: "строка 1\nстрока 2 исправлена\nстрока 3"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		// Изменение только меток не создает ревизию
+		w = doRequest(r, http.MethodPut, notePath, token, models.NoteInput{Title: "Чистовик", Content: "строка 1\nстрока 2 исправлена\nстрока 3", Tags: []string{"текст"}})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doRequest(r, http.MethodGet, notePath+"/revisions", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var revisions []models.NoteRevision
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+		if assert.Len(t, revisions, 3) {
+			assert.Equal(t, 3, revisions[0].Revision, "Ревизии возвращаются от новой к старой")
+			assert.Equal(t, "Чистовик", revisions[0].Title)
+			assert.Equal(t, "Черновик", revisions[2].Title)
+			assert.Equal(t, userID, revisions[0].UserID)
+		}
+	})
+
+	t.Run("GetNoteRevision - Successful and Not Found", func(t *testing.T) {
+		t.Log("Запуск: GetNoteRevision - Получение ревизии")
+		w := doRequest(r, http.MethodGet, notePath+"/revisions/1", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var revision models.NoteRevision
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &revision))
+		assert.Equal(t, "строка 1\nстрока 2\nстрока 3", revision.Content)
+
+		w = doRequest(r, http.MethodGet, notePath+"/revisions/42", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doRequest(r, http.MethodGet, notePath+"/revisions/1", token2, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, "Ревизии чужой заметки недоступны")
+	})
+
+	t.Run("DiffNoteRevisions - Unified diff", func(t *testing.T) {
+		t.Log("Запуск: DiffNoteRevisions - Unified diff между ревизиями")
+		w := doRequest(r, http.MethodGet, notePath+"/revisions/diff?from=1&to=3", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var diff models.RevisionDiff
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+		assert.Contains(t, diff.Diff, "--- revision 1")
+		assert.Contains(t, diff.Diff, "+++ revision 3")
+		assert.Contains(t, diff.Diff, "-Черновик")
+		assert.Contains(t, diff.Diff, "+Чистовик")
+		assert.Contains(t, diff.Diff, "-строка 2\n")
+		assert.Contains(t, diff.Diff, "+строка 2 исправлена")
+		assert.NotContains(t, diff.Diff, "-строка 3", "Неизмененные строки не должны попадать в diff как удаленные")
+
+		w = doRequest(r, http.MethodGet, notePath+"/revisions/diff?from=1", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("RestoreNoteRevision - Successful", func(t *testing.T) {
+		t.Log("Запуск: RestoreNoteRevision - Восстановление ревизии")
+		w := doRequest(r, http.MethodPost, notePath+"/revisions/1/restore", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var restored models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+		assert.Equal(t, "Черновик", restored.Title)
+		assert.Equal(t, "строка 1\nстрока 2\nстрока 3", restored.Content)
+
+		var revision models.NoteRevision
+		assert.NoError(t, testDB.Where("note_id = ?", note.ID).Order("revision DESC").First(&revision).Error)
+		assert.Equal(t, 4, revision.Revision, "Восстановление создает новую ревизию")
+		if assert.NotNil(t, revision.RestoredFrom) {
+			assert.Equal(t, 1, *revision.RestoredFrom)
+		}
+	})
+
+	t.Run("NoteRevision - Immutable", func(t *testing.T) {
+		t.Log("Запуск: NoteRevision - Ревизии нельзя изменить")
+		var revision models.NoteRevision
+		assert.NoError(t, testDB.Where("note_id = ? AND revision = 1", note.ID).First(&revision).Error)
+		revision.Title = "Подмена"
+		assert.Error(t, testDB.Save(&revision).Error)
+	})
+
+	t.Run("UpdateNote - Backfills revision for older notes", func(t *testing.T) {
+		t.Log("Запуск: UpdateNote - Исходная версия старой заметки сохраняется")
+		legacy := models.Note{Title: "Старая", Content: "Исходный текст", UserID: userID}
+		assert.NoError(t, testDB.Create(&legacy).Error)
+		legacyPath := "/notes/" + strconv.FormatUint(uint64(legacy.ID), 10)
+
+		w := doRequest(r, http.MethodPut, legacyPath, token, models.NoteInput{Title: "Старая", Content: "Новый текст"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doRequest(r, http.MethodGet, legacyPath+"/revisions/1", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Исходный текст")
+	})
+
+	t.Run("UpdateNote - Concurrent updates get distinct revisions", func(t *testing.T) {
+		t.Log("Запуск: UpdateNote - Одновременные изменения получают разные номера ревизий")
+		// Заметка без истории: первые изменения одновременно сохраняют и ее исходную версию
+		shared := models.Note{Title: "Общая", Content: "версия 0", UserID: userID}
+		assert.NoError(t, testDB.Create(&shared).Error)
+		sharedPath := "/notes/" + strconv.FormatUint(uint64(shared.ID), 10)
+
+		const updates = 32
+		codes := make([]int, updates)
+		var wg sync.WaitGroup
+		for i := range updates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				content := "версия " + strconv.Itoa(i+1)
+				codes[i] = doRequest(r, http.MethodPut, sharedPath, token, models.NoteInput{Title: "Общая", Content: content}).Code
+			}()
+		}
+		wg.Wait()
+		for i, code := range codes {
+			assert.Equal(t, http.StatusOK, code, "изменение %d", i+1)
+		}
+
+		var revisions []models.NoteRevision
+		assert.NoError(t, testDB.Where("note_id = ?", shared.ID).Order("revision").Find(&revisions).Error)
+		if assert.Len(t, revisions, updates+1) {
+			for i, revision := range revisions {
+				assert.Equal(t, i+1, revision.Revision, "номера ревизий идут подряд")
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// ShareNote godoc
+// @Summary Открыть доступ к заметке
+// @Description Выдает другому пользователю (по имени или email) доступ viewer или editor. Повторный вызов меняет уровень доступа. Доступно только владельцу.
+// @Description Ответ одинаков независимо от того, существует ли пользователь; выданные доступы возвращает GET /notes/{id}/shares.
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param share body models.ShareNoteInput true "Пользователь и уровень доступа"
+// @Security ApiKeyAuth
+// @Success 202 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/shares [post]
+func (h *NoteHandler) ShareNote(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionOwner)
+	if !ok {
+		return
+	}
+
+	var input models.ShareNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	// Ответ не должен выдавать, зарегистрирован ли пользователь, иначе по нему можно перебирать email
+	const message = "Если пользователь зарегистрирован, ему открыт доступ к заметке"
+
+	var grantee models.User
+	err := withRequest(c, h.db).Where("username = ? OR email = ?", input.Identifier, input.Identifier).First(&grantee).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusAccepted, gin.H{"message": message})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске пользователя"})
+		return
+	}
+	if grantee.ID == note.UserID {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Нельзя открыть доступ владельцу заметки"})
+		return
+	}
+
+	var share models.NoteShare
+	err = withRequest(c, h.db).Where("note_id = ? AND user_id = ?", note.ID, grantee.ID).First(&share).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		share = models.NoteShare{NoteID: note.ID, UserID: grantee.ID, Permission: input.Permission, GrantedBy: userID}
+		err = withRequest(c, h.db).Create(&share).Error
+	case err == nil:
+		share.Permission = input.Permission
+		share.GrantedBy = userID
+		err = withRequest(c, h.db).Save(&share).Error
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось открыть доступ к заметке"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": message})
+}
+
+// GetNoteShares godoc
+// @Summary Список доступов к заметке
+// @Description Возвращает пользователей, которым открыта заметка. Доступно только владельцу.
+// @Tags shares
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.NoteShareInfo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/shares [get]
+func (h *NoteHandler) GetNoteShares(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionOwner)
+	if !ok {
+		return
+	}
+
+	shares := []models.NoteShareInfo{}
+	err := withRequest(c, h.db).Table("note_shares").
+		Select("note_shares.user_id, users.username, note_shares.permission, note_shares.created_at").
+		Joins("JOIN users ON users.id = note_shares.user_id").
+		Where("note_shares.note_id = ?", note.ID).
+		Order("users.username").
+		Scan(&shares).Error
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении доступов"})
+		return
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// RevokeNoteShare godoc
+// @Summary Закрыть доступ к заметке
+// @Description Отзывает доступ пользователя к заметке. Владелец может отозвать любой доступ, остальные — только собственный.
+// @Tags shares
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param userId path int true "ID пользователя"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/shares/{userId} [delete]
+func (h *NoteHandler) RevokeNoteShare(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionViewer)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID пользователя"})
+		return
+	}
+	if note.UserID != userID && uint(targetID) != userID {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: "Недостаточно прав для этой операции с заметкой"})
+		return
+	}
+
+	result := withRequest(c, h.db).Where("note_id = ? AND user_id = ?", note.ID, uint(targetID)).Delete(&models.NoteShare{})
+	if result.Error != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось закрыть доступ к заметке"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Доступ не найден"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Доступ к заметке закрыт"})
+}
+
+// GetSharedWithMe godoc
+// @Summary Заметки, открытые мне
+// @Description Возвращает заметки других пользователей, к которым у текущего пользователя есть доступ, начиная с недавно измененных
+// @Tags shares
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.SharedNote
+// @Failure 500 {object} models.ErrorResponse
+// @Router /notes/shared-with-me [get]
+func (h *NoteHandler) GetSharedWithMe(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	var shares []models.NoteShare
+	if err := withRequest(c, h.db).Where("user_id = ?", userID).Find(&shares).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении заметок"})
+		return
+	}
+	result := []models.SharedNote{}
+	if len(shares) == 0 {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	permissions := map[uint]string{}
+	noteIDs := make([]uint, 0, len(shares))
+	for _, share := range shares {
+		permissions[share.NoteID] = share.Permission
+		noteIDs = append(noteIDs, share.NoteID)
+	}
+
+	var notes []models.Note
+	if err := withRequest(c, h.db).Preload("Tags").Where("id IN ?", noteIDs).Order("updated_at DESC, id DESC").Find(&notes).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении заметок"})
+		return
+	}
+
+	ownerIDs := make([]uint, 0, len(notes))
+	for _, note := range notes {
+		ownerIDs = append(ownerIDs, note.UserID)
+	}
+	var owners []models.User
+	if err := withRequest(c, h.db).Select("id, username").Where("id IN ?", ownerIDs).Find(&owners).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении заметок"})
+		return
+	}
+	usernames := map[uint]string{}
+	for _, owner := range owners {
+		usernames[owner.ID] = owner.Username
+	}
+
+	for _, note := range notes {
+		result = append(result, models.SharedNote{
+			Note:          note,
+			Permission:    permissions[note.ID],
+			OwnerUsername: usernames[note.UserID],
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,130 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes/shared-with-me", noteHandler.GetSharedWithMe)
+	r.GET("/notes/:id", noteHandler.GetNote)
+	r.PUT("/notes/:id", noteHandler.UpdateNote)
+	r.DELETE("/notes/:id", noteHandler.DeleteNote)
+	r.GET("/notes/:id/shares", noteHandler.GetNoteShares)
+	r.POST("/notes/:id/shares", noteHandler.ShareNote)
+	r.DELETE("/notes/:id/shares/:userId", noteHandler.RevokeNoteShare)
+
+	ownerToken, ownerID := registerAndLoginUser(t, testDB, "testuser_shares", "shares@example.com", "password123")
+	viewerToken, viewerID := registerAndLoginUser(t, testDB, "viewer_shares", "viewer_shares@example.com", "password123")
+	editorToken, editorID := registerAndLoginUser(t, testDB, "editor_shares", "editor_shares@example.com", "password123")
+	strangerToken, strangerID := registerAndLoginUser(t, testDB, "stranger_shares", "stranger_shares@example.com", "password123")
+
+	note := models.Note{Title: "Общая", Content: "Контент", UserID: ownerID}
+	assert.NoError(t, testDB.Create(&note).Error)
+	notePath := "/notes/" + strconv.FormatUint(uint64(note.ID), 10)
+
+	t.Run("ShareNote - Grant access", func(t *testing.T) {
+		t.Log("Запуск: ShareNote - Выдача доступа")
+		w := doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "viewer_shares", Permission: "viewer"})
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		w = doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "editor_shares@example.com", Permission: "viewer"})
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		w = doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "editor_shares", Permission: "editor"})
+		assert.Equal(t, http.StatusAccepted, w.Code, "Повторная выдача меняет уровень доступа")
+
+		w = doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "testuser_shares", Permission: "viewer"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Владельцу доступ не выдается")
+		w = doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "viewer_shares", Permission: "owner"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Права владельца передать нельзя")
+		w = doRequest(r, http.MethodPost, notePath+"/shares", editorToken, models.ShareNoteInput{Identifier: "stranger_shares", Permission: "viewer"})
+		assert.Equal(t, http.StatusForbidden, w.Code, "Редактор не может выдавать доступ")
+
+		w = doRequest(r, http.MethodGet, notePath+"/shares", ownerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var shares []models.NoteShareInfo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &shares))
+		if assert.Len(t, shares, 2) {
+			assert.Equal(t, "editor_shares", shares[0].Username)
+			assert.Equal(t, "editor", shares[0].Permission)
+			assert.Equal(t, "viewer", shares[1].Permission)
+		}
+	})
+
+	t.Run("ShareNote - Unknown user not revealed", func(t *testing.T) {
+		t.Log("Запуск: ShareNote - Ответ не раскрывает, зарегистрирован ли пользователь")
+		known := doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "stranger_shares@example.com", Permission: "viewer"})
+		unknown := doRequest(r, http.MethodPost, notePath+"/shares", ownerToken, models.ShareNoteInput{Identifier: "nobody@example.com", Permission: "viewer"})
+		assert.Equal(t, http.StatusAccepted, known.Code)
+		assert.Equal(t, known.Code, unknown.Code)
+		assert.Equal(t, known.Body.String(), unknown.Body.String())
+		assert.NotContains(t, known.Body.String(), "stranger_shares")
+
+		var count int64
+		testDB.Model(&models.NoteShare{}).Where("note_id = ? AND user_id = ?", note.ID, strangerID).Count(&count)
+		assert.Equal(t, int64(1), count, "Существующему пользователю доступ выдан")
+		testDB.Where("note_id = ? AND user_id = ?", note.ID, strangerID).Delete(&models.NoteShare{})
+	})
+
+	t.Run("Permissions - Viewer, editor and stranger", func(t *testing.T) {
+		t.Log("Запуск: Permissions - Проверка уровней доступа")
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, notePath, viewerToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodGet, notePath, strangerToken, nil).Code)
+
+		update := models.NoteInput{Title: "Общая", Content: "Исправлено редактором"}
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodPut, notePath, viewerToken, update).Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodPut, notePath, strangerToken, update).Code)
+		w := doRequest(r, http.MethodPut, notePath, editorToken, update)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Исправлено редактором")
+
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodDelete, notePath, editorToken, nil).Code, "Удалять может только владелец")
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodDelete, notePath+"?permanent=true", editorToken, nil).Code)
+	})
+
+	t.Run("GetSharedWithMe - Lists shared notes", func(t *testing.T) {
+		t.Log("Запуск: GetSharedWithMe - Заметки, открытые пользователю")
+		w := doRequest(r, http.MethodGet, "/notes/shared-with-me", editorToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var notes []models.SharedNote
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		if assert.Len(t, notes, 1) {
+			assert.Equal(t, note.ID, notes[0].ID)
+			assert.Equal(t, "editor", notes[0].Permission)
+			assert.Equal(t, "testuser_shares", notes[0].OwnerUsername)
+		}
+
+		w = doRequest(r, http.MethodGet, "/notes/shared-with-me", strangerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("RevokeNoteShare - Owner and self", func(t *testing.T) {
+		t.Log("Запуск: RevokeNoteShare - Отзыв доступа")
+		viewerPath := notePath + "/shares/" + strconv.FormatUint(uint64(viewerID), 10)
+		editorPath := notePath + "/shares/" + strconv.FormatUint(uint64(editorID), 10)
+
+		assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodDelete, viewerPath, editorToken, nil).Code, "Чужой доступ отзывает только владелец")
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, editorPath, editorToken, nil).Code, "Пользователь может отказаться от доступа")
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, viewerPath, ownerToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodDelete, viewerPath, ownerToken, nil).Code)
+
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodGet, notePath, viewerToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, notePath, ownerToken, nil).Code)
+	})
+}
@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// sharePasswordHeader — заголовок, в котором клиенты API передают пароль публичной ссылки.
+// HTML-страница отправляет пароль полем формы password.
+const sharePasswordHeader = "X-Share-Password"
+
+var publicNoteTemplate = template.Must(template.New("public").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Note}}{{.Note.Title}}{{else}}Заметка{{end}}</title>
+<style>body{max-width:48rem;margin:2rem auto;padding:0 1rem;font-family:sans-serif;line-height:1.5}pre{white-space:pre-wrap;font-family:inherit}small{color:#666}</style>
+</head>
+<body>
+{{- if .Note}}
+<h1>{{.Note.Title}}</h1>
+<small>Обновлено {{.Note.UpdatedAt.Format "02.01.2006 15:04"}}</small>
+<pre>{{.Note.Content}}</pre>
+{{- else if .AskPassword}}
+<h1>Заметка защищена паролем</h1>
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+<form method="post">
+<input type="password" name="password" placeholder="Пароль" autofocus required>
+<button type="submit">Открыть</button>
+</form>
+{{- else}}
+<h1>{{.Error}}</h1>
+{{- end}}
+</body>
+</html>
+`))
+
+type publicNotePage struct {
+	Note        *models.PublicNote
+	AskPassword bool
+	Error       string
+}
+
+// hashShareToken возвращает SHA-256 токена в hex: по нему ссылка ищется в базе.
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newShareToken генерирует случайный токен ссылки (256 бит).
+func newShareToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CreateShareLink godoc
+// @Summary Создать публичную ссылку
+// @Description Создает ссылку только для чтения, по которой заметку можно открыть без учетной записи. Срок действия, лимит просмотров и пароль необязательны. Токен возвращается только в этом ответе.
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param link body models.ShareLinkInput false "Ограничения ссылки"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.CreatedShareLink
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/links [post]
+func (h *NoteHandler) CreateShareLink(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionOwner)
+	if !ok {
+		return
+	}
+
+	var input models.ShareLinkInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+			return
+		}
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Срок действия ссылки должен быть в будущем"})
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать ссылку"})
+		return
+	}
+	link := models.ShareLink{
+		NoteID:    note.ID,
+		TokenHash: hashShareToken(token),
+		ExpiresAt: input.ExpiresAt,
+		MaxViews:  input.MaxViews,
+		CreatedBy: userID,
+	}
+	if input.Password != "" {
+		hashed, err := utils.HashPassword(input.Password, bcrypt.DefaultCost)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать ссылку"})
+			return
+		}
+		link.PasswordHash = hashed
+		link.HasPassword = true
+	}
+	if err := withRequest(c, h.db).Create(&link).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать ссылку"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreatedShareLink{ShareLink: link, Token: token, URL: "/public/" + token})
+}
+
+// GetShareLinks godoc
+// @Summary Публичные ссылки заметки
+// @Description Возвращает действующие и исчерпанные ссылки заметки без токенов
+// @Tags links
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.ShareLink
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/links [get]
+func (h *NoteHandler) GetShareLinks(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionOwner)
+	if !ok {
+		return
+	}
+
+	links := []models.ShareLink{}
+	if err := withRequest(c, h.db).Where("note_id = ?", note.ID).Order("created_at DESC, id DESC").Find(&links).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении ссылок"})
+		return
+	}
+	for i := range links {
+		links[i].HasPassword = links[i].PasswordHash != ""
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink godoc
+// @Summary Отозвать публичную ссылку
+// @Tags links
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param linkId path int true "ID ссылки"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/links/{linkId} [delete]
+func (h *NoteHandler) RevokeShareLink(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findNote(c, userID, models.PermissionOwner)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.ParseUint(c.Param("linkId"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID ссылки"})
+		return
+	}
+	result := withRequest(c, h.db).Where("id = ? AND note_id = ?", uint(linkID), note.ID).Delete(&models.ShareLink{})
+	if result.Error != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось отозвать ссылку"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Ссылка не найдена"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Ссылка отозвана"})
+}
+
+// GetPublicNote godoc
+// @Summary Открыть заметку по публичной ссылке
+// @Description Не требует авторизации. Отдает JSON или HTML в зависимости от заголовка Accept (или параметра format=html). Пароль передается в заголовке X-Share-Password или полем формы password. Каждый успешный просмотр уменьшает оставшийся лимит.
+// @Tags links
+// @Produce json,html
+// @Param token path string true "Токен ссылки"
+// @Param format query string false "Формат ответа: json или html"
+// @Param X-Share-Password header string false "Пароль ссылки"
+// @Success 200 {object} models.PublicNote
+// @Failure 401 {object} models.ErrorResponse "Требуется пароль или пароль неверный"
+// @Failure 404 {object} models.ErrorResponse "Ссылка не найдена или отозвана"
+// @Failure 410 {object} models.ErrorResponse "Срок действия или лимит просмотров ссылки исчерпан"
+// @Router /public/{token} [get]
+// @Router /public/{token} [post]
+func (h *NoteHandler) GetPublicNote(c *gin.Context) {
+	asHTML := c.Query("format") == "html" ||
+		(c.Query("format") == "" && c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML)
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	fail := func(status int, message string, askPassword bool) {
+		if asHTML {
+			c.Render(status, render.HTML{Template: publicNoteTemplate, Name: "public", Data: publicNotePage{AskPassword: askPassword, Error: message}})
+			c.Abort()
+			return
+		}
+		c.AbortWithStatusJSON(status, models.ErrorResponse{Error: message})
+	}
+
+	var link models.ShareLink
+	if err := withRequest(c, h.db).Where("token_hash = ?", hashShareToken(c.Param("token"))).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fail(http.StatusNotFound, "Ссылка не найдена или отозвана", false)
+			return
+		}
+		fail(http.StatusInternalServerError, "Ошибка при открытии ссылки", false)
+		return
+	}
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		fail(http.StatusGone, "Срок действия ссылки истек", false)
+		return
+	}
+	if link.PasswordHash != "" {
+		password := c.GetHeader(sharePasswordHeader)
+		if password == "" {
+			password = c.PostForm("password")
+		}
+		if password == "" {
+			fail(http.StatusUnauthorized, "Для просмотра заметки нужен пароль", true)
+			return
+		}
+		if utils.ComparePassword(link.PasswordHash, password) != nil {
+			fail(http.StatusUnauthorized, "Неверный пароль", true)
+			return
+		}
+	}
+
+	var note models.Note
+	if err := withRequest(c, h.db).First(&note, link.NoteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fail(http.StatusNotFound, "Ссылка не найдена или отозвана", false)
+			return
+		}
+		fail(http.StatusInternalServerError, "Ошибка при открытии ссылки", false)
+		return
+	}
+
+	// Счетчик увеличивается одним условным UPDATE, чтобы параллельные просмотры не превысили лимит
+	result := withRequest(c, h.db).Model(&models.ShareLink{}).
+		Where("id = ? AND (max_views IS NULL OR view_count < max_views)", link.ID).
+		UpdateColumn("view_count", gorm.Expr("view_count + 1"))
+	if result.Error != nil {
+		fail(http.StatusInternalServerError, "Ошибка при открытии ссылки", false)
+		return
+	}
+	if result.RowsAffected == 0 {
+		fail(http.StatusGone, "Лимит просмотров ссылки исчерпан", false)
+		return
+	}
+
+	public := models.PublicNote{Title: note.Title, Content: note.Content, UpdatedAt: note.UpdatedAt}
+	if asHTML {
+		c.Render(http.StatusOK, render.HTML{Template: publicNoteTemplate, Name: "public", Data: publicNotePage{Note: &public}})
+		return
+	}
+	c.JSON(http.StatusOK, public)
+}
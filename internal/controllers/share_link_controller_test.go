@@ -0,0 +1,145 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareLinkController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.GET("/public/:token", noteHandler.GetPublicNote)
+	r.POST("/public/:token", noteHandler.GetPublicNote)
+	authorized := r.Group("/notes").Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	authorized.DELETE("/:id", noteHandler.DeleteNote)
+	authorized.GET("/:id/links", noteHandler.GetShareLinks)
+	authorized.POST("/:id/links", noteHandler.CreateShareLink)
+	authorized.DELETE("/:id/links/:linkId", noteHandler.RevokeShareLink)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_links", "links@example.com", "password123")
+	token2, _ := registerAndLoginUser(t, testDB, "anotheruser_links", "another_links@example.com", "password123")
+
+	createLink := func(t *testing.T, notePath string, input models.ShareLinkInput) models.CreatedShareLink {
+		w := doRequest(r, http.MethodPost, notePath+"/links", token, input)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var link models.CreatedShareLink
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &link))
+		return link
+	}
+
+	note := models.Note{Title: "Публичная", Content: "<script>alert(1)</script>", UserID: userID}
+	assert.NoError(t, testDB.Create(&note).Error)
+	notePath := "/notes/" + strconv.FormatUint(uint64(note.ID), 10)
+
+	t.Run("GetPublicNote - JSON and HTML", func(t *testing.T) {
+		t.Log("Запуск: GetPublicNote - Просмотр заметки без авторизации")
+		link := createLink(t, notePath, models.ShareLinkInput{})
+		assert.NotEmpty(t, link.Token)
+		assert.Equal(t, "/public/"+link.Token, link.URL)
+
+		w := doRequest(r, http.MethodGet, link.URL, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var public models.PublicNote
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &public))
+		assert.Equal(t, "Публичная", public.Title)
+		assert.NotContains(t, w.Body.String(), "user_id", "Служебные поля заметки не раскрываются")
+
+		req := newRequest(http.MethodGet, link.URL, "", nil)
+		req.Header.Set("Accept", "text/html")
+		w = serve(r, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "<h1>Публичная</h1>")
+		assert.Contains(t, w.Body.String(), "&lt;script&gt;", "Содержимое заметки экранируется")
+
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodGet, "/public/unknown", "", nil).Code)
+	})
+
+	t.Run("CreateShareLink - Validation", func(t *testing.T) {
+		t.Log("Запуск: CreateShareLink - Проверка параметров и прав")
+		past := time.Now().Add(-time.Hour)
+		w := doRequest(r, http.MethodPost, notePath+"/links", token, models.ShareLinkInput{ExpiresAt: &past})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		zero := 0
+		w = doRequest(r, http.MethodPost, notePath+"/links", token, models.ShareLinkInput{MaxViews: &zero})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doRequest(r, http.MethodPost, notePath+"/links", token2, models.ShareLinkInput{})
+		assert.Equal(t, http.StatusNotFound, w.Code, "Чужую заметку опубликовать нельзя")
+	})
+
+	t.Run("GetPublicNote - Max views and expiry", func(t *testing.T) {
+		t.Log("Запуск: GetPublicNote - Лимит просмотров и срок действия")
+		limit := 2
+		link := createLink(t, notePath, models.ShareLinkInput{MaxViews: &limit})
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, link.URL, "", nil).Code)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, link.URL, "", nil).Code)
+		assert.Equal(t, http.StatusGone, doRequest(r, http.MethodGet, link.URL, "", nil).Code)
+
+		soon := time.Now().Add(time.Hour)
+		expiring := createLink(t, notePath, models.ShareLinkInput{ExpiresAt: &soon})
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, expiring.URL, "", nil).Code)
+		assert.NoError(t, testDB.Model(&models.ShareLink{}).Where("id = ?", expiring.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+		assert.Equal(t, http.StatusGone, doRequest(r, http.MethodGet, expiring.URL, "", nil).Code)
+	})
+
+	t.Run("GetPublicNote - Password", func(t *testing.T) {
+		t.Log("Запуск: GetPublicNote - Ссылка с паролем")
+		link := createLink(t, notePath, models.ShareLinkInput{Password: "secret"})
+		assert.True(t, link.HasPassword)
+		assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, link.URL, "", nil).Code)
+
+		req := newRequest(http.MethodGet, link.URL, "", nil)
+		req.Header.Set("X-Share-Password", "wrong")
+		w := serve(r, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req = newRequest(http.MethodGet, link.URL, "", nil)
+		req.Header.Set("X-Share-Password", "secret")
+		w = serve(r, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		form := url.Values{"password": {"secret"}}
+		req, _ = http.NewRequest(http.MethodPost, link.URL+"?format=html", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = serve(r, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "<h1>Публичная</h1>")
+	})
+
+	t.Run("RevokeShareLink - Link stops working", func(t *testing.T) {
+		t.Log("Запуск: RevokeShareLink - Отзыв ссылки")
+		link := createLink(t, notePath, models.ShareLinkInput{})
+
+		w := doRequest(r, http.MethodGet, notePath+"/links", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), link.Token, "Токен не возвращается повторно")
+
+		linkPath := notePath + "/links/" + strconv.FormatUint(uint64(link.ID), 10)
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodDelete, linkPath, token2, nil).Code)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, linkPath, token, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodGet, link.URL, "", nil).Code)
+	})
+
+	t.Run("GetPublicNote - Deleted note", func(t *testing.T) {
+		t.Log("Запуск: GetPublicNote - Заметка в корзине недоступна по ссылке")
+		link := createLink(t, notePath, models.ShareLinkInput{})
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, notePath, token, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(r, http.MethodGet, link.URL, "", nil).Code)
+	})
+}
@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// TagHandler обрабатывает запросы к меткам пользователя.
+type TagHandler struct {
+	db *gorm.DB
+}
+
+// NewTagHandler создает TagHandler, работающий с подключением tx.
+func NewTagHandler(tx *gorm.DB) *TagHandler {
+	return &TagHandler{db: tx}
+}
+
+// applyTagFilter оставляет заметки с метками из параметра tags (через запятую).
+// tag_mode=or (по умолчанию) — хотя бы одна из меток, tag_mode=and — все метки сразу.
+func applyTagFilter(c *gin.Context, tx *gorm.DB, query *gorm.DB) (*gorm.DB, error) {
+	tagsParam := c.Query("tags")
+	if tagsParam == "" {
+		return query, nil
+	}
+
+	// Повторы отбрасываются: в режиме and число меток сравнивается с числом совпавших
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(tagsParam, ",") {
+		if name = models.NormalizeTagName(name); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return query, nil
+	}
+
+	sub := tx.Table("note_tags").
+		Select("note_tags.note_id").
+		Joins("JOIN tags ON tags.id = note_tags.tag_id").
+		Where("tags.name IN ?", names)
+
+	switch c.DefaultQuery("tag_mode", "or") {
+	case "or":
+	case "and":
+		sub = sub.Group("note_tags.note_id").Having("COUNT(DISTINCT tags.id) = ?", len(names))
+	default:
+		return query, errors.New("Параметр tag_mode должен быть and или or")
+	}
+	return query.Where("notes.id IN (?)", sub), nil
+}
+
+// findUserTag загружает метку текущего пользователя по ID из параметра пути.
+// При ошибке ответ уже отправлен.
+func (h *TagHandler) findUserTag(c *gin.Context, userID uint, idStr string) (models.Tag, bool) {
+	var tag models.Tag
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный формат ID метки"})
+		return tag, false
+	}
+	if err := withRequest(c, h.db).Where("id = ? AND user_id = ?", uint(id), userID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Метка не найдена"})
+			return tag, false
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске метки"})
+		return tag, false
+	}
+	return tag, true
+}
+
+// GetTags godoc
+// @Summary Получить метки
+// @Description Возвращает все метки текущего пользователя с количеством заметок для каждой
+// @Tags tags
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.TagWithCount
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tags [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	tags := []models.TagWithCount{}
+	err := withRequest(c, h.db).Table("tags").
+		Select("tags.*, COUNT(notes.id) AS note_count").
+		Joins("LEFT JOIN note_tags ON note_tags.tag_id = tags.id").
+		Joins("LEFT JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id").
+		Order("tags.name").
+		Scan(&tags).Error
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении меток"})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag godoc
+// @Summary Создать метку
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body models.TagInput true "Имя метки"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /tags [post]
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input models.TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	name := models.NormalizeTagName(input.Name)
+	if name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Имя метки не может быть пустым"})
+		return
+	}
+
+	// Занятое имя определяет уникальный индекс: проверка перед вставкой пропустила бы
+	// одновременно созданную метку
+	tag := models.Tag{Name: name, UserID: userID}
+	if err := withRequest(c, h.db).Create(&tag).Error; err != nil {
+		if db.IsDuplicate(h.db, err) {
+			c.AbortWithStatusJSON(http.StatusConflict, models.ErrorResponse{Error: "Метка с таким именем уже существует"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось создать метку"})
+		return
+	}
+	c.JSON(http.StatusCreated, tag)
+}
+
+// RenameTag godoc
+// @Summary Переименовать метку
+// @Description Меняет имя метки. Если метка с новым именем уже есть, используйте объединение меток.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "ID метки"
+// @Param tag body models.TagInput true "Новое имя метки"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /tags/{id} [put]
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	tag, ok := h.findUserTag(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input models.TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	name := models.NormalizeTagName(input.Name)
+	if name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Имя метки не может быть пустым"})
+		return
+	}
+
+	if err := withRequest(c, h.db).Model(&tag).Update("name", name).Error; err != nil {
+		if db.IsDuplicate(h.db, err) {
+			c.AbortWithStatusJSON(http.StatusConflict, models.ErrorResponse{Error: "Метка с таким именем уже существует, используйте объединение меток"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось переименовать метку"})
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTag godoc
+// @Summary Объединить метки
+// @Description Переносит все заметки с метки {id} на метку target_id и удаляет метку {id}
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "ID объединяемой метки"
+// @Param input body models.MergeTagInput true "ID метки, в которую выполняется объединение"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /tags/{id}/merge [post]
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	source, ok := h.findUserTag(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input models.MergeTagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Неверный ввод: " + err.Error()})
+		return
+	}
+	if input.TargetID == source.ID {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "Нельзя объединить метку саму с собой"})
+		return
+	}
+	target, ok := h.findUserTag(c, userID, strconv.FormatUint(uint64(input.TargetID), 10))
+	if !ok {
+		return
+	}
+
+	err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		// Переносим связи, которых у целевой метки еще нет
+		if err := tx.Exec(`INSERT INTO note_tags (note_id, tag_id)
+			SELECT note_id, ? FROM note_tags
+			WHERE tag_id = ? AND note_id NOT IN (SELECT note_id FROM note_tags WHERE tag_id = ?)`,
+			target.ID, source.ID, target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось объединить метки"})
+		return
+	}
+	c.JSON(http.StatusOK, target)
+}
+
+// DeleteTag godoc
+// @Summary Удалить метку
+// @Description Удаляет метку и снимает её со всех заметок. Сами заметки не удаляются.
+// @Tags tags
+// @Produce json
+// @Param id path int true "ID метки"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	tag, ok := h.findUserTag(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := withRequest(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при удалении метки"})
+		return
+	}
+	c.JSON(http.StatusOK, models.MessageResponse{Message: "Метка успешно удалена"})
+}
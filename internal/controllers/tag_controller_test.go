@@ -0,0 +1,224 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	tagHandler := controllers.NewTagHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes", noteHandler.GetNotes)
+	r.POST("/notes", noteHandler.CreateNote)
+	r.PUT("/notes/:id", noteHandler.UpdateNote)
+	r.GET("/tags", tagHandler.GetTags)
+	r.POST("/tags", tagHandler.CreateTag)
+	r.PUT("/tags/:id", tagHandler.RenameTag)
+	r.POST("/tags/:id/merge", tagHandler.MergeTag)
+	r.DELETE("/tags/:id", tagHandler.DeleteTag)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_tags", "tags@example.com", "password123")
+	token2, _ := registerAndLoginUser(t, testDB, "anotheruser_tags", "another_tags@example.com", "password123")
+
+	tagNames := func(note models.Note) []string {
+		names := []string{}
+		for _, tag := range note.Tags {
+			names = append(names, tag.Name)
+		}
+		return names
+	}
+
+	findTag := func(t *testing.T, name string) models.TagWithCount {
+		w := doRequest(r, http.MethodGet, "/tags", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var tags []models.TagWithCount
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+		for _, tag := range tags {
+			if tag.Name == name {
+				return tag
+			}
+		}
+		t.Fatalf("метка %q не найдена", name)
+		return models.TagWithCount{}
+	}
+
+	var workNoteID uint
+
+	t.Run("CreateNote - With tags", func(t *testing.T) {
+		t.Log("Запуск: CreateNote - Создание заметки с метками")
+		w := doRequest(r, http.MethodPost, "/notes", token, models.NoteInput{
+			Title: "Отчет", Content: "Квартальный отчет", Tags: []string{"Работа", " срочно ", "работа"},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var note models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+		assert.ElementsMatch(t, []string{"работа", "срочно"}, tagNames(note), "Имена меток нормализуются, дубликаты отбрасываются")
+		workNoteID = note.ID
+
+		w = doRequest(r, http.MethodPost, "/notes", token, models.NoteInput{Title: "Отпуск", Content: "Билеты", Tags: []string{"личное"}})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = doRequest(r, http.MethodPost, "/notes", token, models.NoteInput{Title: "Звонок", Content: "Позвонить", Tags: []string{"работа"}})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("UpdateNote - Tags", func(t *testing.T) {
+		t.Log("Запуск: UpdateNote - Изменение меток заметки")
+		url := "/notes/" + strconv.FormatUint(uint64(workNoteID), 10)
+
+		// Без поля tags метки не меняются
+		w := doRequest(r, http.MethodPut, url, token, map[string]string{"title": "Отчет v2", "content": "Квартальный отчет"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var note models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+		assert.ElementsMatch(t, []string{"работа", "срочно"}, tagNames(note))
+
+		w = doRequest(r, http.MethodPut, url, token, models.NoteInput{Title: "Отчет v2", Content: "Квартальный отчет", Tags: []string{"работа", "отчеты"}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &note))
+		assert.ElementsMatch(t, []string{"работа", "отчеты"}, tagNames(note))
+	})
+
+	t.Run("GetTags - Note counts", func(t *testing.T) {
+		t.Log("Запуск: GetTags - Количество заметок по меткам")
+		assert.Equal(t, int64(2), findTag(t, "работа").NoteCount)
+		assert.Equal(t, int64(1), findTag(t, "личное").NoteCount)
+		assert.Equal(t, int64(0), findTag(t, "срочно").NoteCount, "Снятая метка остается, но без заметок")
+
+		// Метки другого пользователя не видны
+		w := doRequest(r, http.MethodGet, "/tags", token2, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("GetNotes - Filter by tags", func(t *testing.T) {
+		t.Log("Запуск: GetNotes - Фильтрация по меткам")
+		var notes []models.Note
+
+		w := doRequest(r, http.MethodGet, "/notes?tags=работа,личное", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		assert.Len(t, notes, 3, "Режим or возвращает заметки хотя бы с одной меткой")
+
+		w = doRequest(r, http.MethodGet, "/notes?tags=работа,отчеты&tag_mode=and", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		if assert.Len(t, notes, 1, "Режим and возвращает заметки со всеми метками") {
+			assert.Equal(t, workNoteID, notes[0].ID)
+		}
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+		w = doRequest(r, http.MethodGet, "/notes?tags=работа,Работа,работа&tag_mode=and", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		assert.Len(t, notes, 2, "Повторы метки в режиме and не мешают совпадению")
+
+		w = doRequest(r, http.MethodGet, "/notes?tags=работа&tag_mode=xor", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("CreateTag - Successful and Conflict", func(t *testing.T) {
+		t.Log("Запуск: CreateTag - Создание метки и конфликт имен")
+		w := doRequest(r, http.MethodPost, "/tags", token, models.TagInput{Name: "Идеи"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var tag models.Tag
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tag))
+		assert.Equal(t, "идеи", tag.Name)
+
+		w = doRequest(r, http.MethodPost, "/tags", token, models.TagInput{Name: "идеи"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("CreateTag - Concurrent duplicates", func(t *testing.T) {
+		t.Log("Запуск: CreateTag - Одновременное создание метки с одним именем")
+		const requests = 8
+		codes := make([]int, requests)
+		var wg sync.WaitGroup
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes[i] = doRequest(r, http.MethodPost, "/tags", token, models.TagInput{Name: "гонка"}).Code
+			}()
+		}
+		wg.Wait()
+
+		created := 0
+		for _, code := range codes {
+			if code == http.StatusCreated {
+				created++
+				continue
+			}
+			assert.Equal(t, http.StatusConflict, code)
+		}
+		assert.Equal(t, 1, created, "Метка создается ровно один раз")
+	})
+
+	t.Run("RenameTag - Successful and Conflict", func(t *testing.T) {
+		t.Log("Запуск: RenameTag - Переименование метки")
+		tag := findTag(t, "отчеты")
+		url := "/tags/" + strconv.FormatUint(uint64(tag.ID), 10)
+
+		w := doRequest(r, http.MethodPut, url, token, models.TagInput{Name: "работа"})
+		assert.Equal(t, http.StatusConflict, w.Code, "Переименование в существующее имя должно вернуть конфликт")
+
+		w = doRequest(r, http.MethodPut, url, token, models.TagInput{Name: "Отчетность"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tag.ID, findTag(t, "отчетность").ID)
+
+		w = doRequest(r, http.MethodPut, url, token2, models.TagInput{Name: "чужое"})
+		assert.Equal(t, http.StatusNotFound, w.Code, "Чужую метку нельзя переименовать")
+	})
+
+	t.Run("MergeTag - Successful", func(t *testing.T) {
+		t.Log("Запуск: MergeTag - Объединение меток")
+		source := findTag(t, "отчетность")
+		target := findTag(t, "личное")
+
+		w := doRequest(r, http.MethodPost, "/tags/"+strconv.FormatUint(uint64(source.ID), 10)+"/merge", token,
+			models.MergeTagInput{TargetID: target.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(2), findTag(t, "личное").NoteCount, "Заметки объединяемой метки переходят к целевой")
+
+		var count int64
+		testDB.Model(&models.Tag{}).Where("id = ?", source.ID).Count(&count)
+		assert.Zero(t, count, "Объединенная метка должна быть удалена")
+
+		w = doRequest(r, http.MethodPost, "/tags/"+strconv.FormatUint(uint64(target.ID), 10)+"/merge", token,
+			models.MergeTagInput{TargetID: target.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("DeleteTag - Successful", func(t *testing.T) {
+		t.Log("Запуск: DeleteTag - Удаление метки")
+		tag := findTag(t, "работа")
+		w := doRequest(r, http.MethodDelete, "/tags/"+strconv.FormatUint(uint64(tag.ID), 10), token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Метка успешно удалена")
+
+		var notes []models.Note
+		w = doRequest(r, http.MethodGet, "/notes?tags=работа", token, nil)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		assert.Empty(t, notes)
+
+		var count int64
+		testDB.Model(&models.Note{}).Where("user_id = ?", userID).Count(&count)
+		assert.Equal(t, int64(3), count, "Заметки не должны удаляться вместе с меткой")
+	})
+}
@@ -0,0 +1,159 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/tracing"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracing.PathRedactor()), sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		provider.Shutdown(t.Context())
+	}()
+
+	testDB := setupTestDB(t)
+	assert.NoError(t, tracing.InstrumentGORM(testDB))
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.New()
+	r.Use(tracing.Middleware("notes-api-test"))
+	r.GET("/notes/:id", middleware.AuthMiddleware(testDB, testConfig().JWT), noteHandler.GetNote)
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/public/:token", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_tracing", "tracing@example.com", "password123")
+	note := models.Note{Title: "Трассировка", Content: "Текст", UserID: userID}
+	assert.NoError(t, testDB.Create(&note).Error)
+
+	// doTracedRequest выполняет запрос с заголовками header, оставляя в recorder только его спаны
+	doTracedRequest := func(url string, header http.Header) *httptest.ResponseRecorder {
+		recorder.Reset()
+		req := newRequest(http.MethodGet, url, token, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		return serve(r, req)
+	}
+	serverSpan := func(spans []sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+		for _, span := range spans {
+			if span.SpanKind() == trace.SpanKindServer {
+				return span
+			}
+		}
+		return nil
+	}
+
+	t.Run("Request - Server span with GORM children", func(t *testing.T) {
+		t.Log("Запуск: Request - Server span with GORM children")
+		w := doTracedRequest(fmt.Sprintf("/notes/%d", note.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		spans := recorder.Ended()
+		server := serverSpan(spans)
+		if assert.NotNil(t, server) {
+			assert.Equal(t, "GET /notes/:id", server.Name())
+			assert.Contains(t, server.Attributes(), attribute.String("http.route", "/notes/:id"))
+		}
+
+		// Preload выполняется внутри основного запроса, поэтому его span — дочерний для span этого запроса
+		parents := map[trace.SpanID]bool{}
+		if server != nil {
+			parents[server.SpanContext().SpanID()] = true
+		}
+		var queries []sdktrace.ReadOnlySpan
+		for _, span := range spans {
+			if strings.HasPrefix(span.Name(), "gorm.") {
+				queries = append(queries, span)
+				parents[span.SpanContext().SpanID()] = true
+			}
+		}
+		for _, span := range queries {
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.True(t, parents[span.Parent().SpanID()], "span %s вне трассы запроса", span.Name())
+			if server != nil {
+				assert.Equal(t, server.SpanContext().TraceID(), span.SpanContext().TraceID())
+			}
+			for _, attr := range span.Attributes() {
+				if attr.Key == "db.query.text" {
+					assert.NotContains(t, attr.Value.AsString(), "Трассировка", "значения параметров не попадают в span")
+				}
+			}
+		}
+		assert.GreaterOrEqual(t, len(queries), 2, "ожидаются spans проверки токена и загрузки заметки")
+	})
+
+	t.Run("Request - Continue traceparent", func(t *testing.T) {
+		t.Log("Запуск: Request - Continue traceparent")
+		traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+		header := http.Header{}
+		header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		w := doTracedRequest(fmt.Sprintf("/notes/%d", note.ID), header)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		server := serverSpan(recorder.Ended())
+		if assert.NotNil(t, server) {
+			assert.Equal(t, traceID, server.SpanContext().TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+			assert.True(t, server.Parent().IsRemote())
+		}
+	})
+
+	t.Run("Request - Record not found is not an error", func(t *testing.T) {
+		t.Log("Запуск: Request - Record not found is not an error")
+		w := doTracedRequest("/notes/999999", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		for _, span := range recorder.Ended() {
+			if strings.HasPrefix(span.Name(), "gorm.") {
+				assert.NotEqual(t, codes.Error, span.Status().Code, span.Name())
+			}
+		}
+	})
+
+	t.Run("Request - Share link token redacted", func(t *testing.T) {
+		t.Log("Запуск: Request - Share link token redacted")
+		w := doTracedRequest("/public/share-secret-token", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		server := serverSpan(recorder.Ended())
+		if assert.NotNil(t, server) {
+			assert.Contains(t, server.Attributes(), attribute.String("url.path", "/public/[REDACTED]"))
+			for _, attr := range server.Attributes() {
+				assert.NotContains(t, attr.Value.Emit(), "share-secret-token", string(attr.Key))
+			}
+		}
+	})
+
+	t.Run("Health - Not traced", func(t *testing.T) {
+		t.Log("Запуск: Health - Not traced")
+		w := doTracedRequest("/healthz", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, serverSpan(recorder.Ended()))
+	})
+}
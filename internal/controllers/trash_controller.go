@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/service"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// trashSortColumns — поля, по которым сортируется корзина.
+var trashSortColumns = map[string]string{
+	"deleted_at": "deleted_at",
+}
+
+// GetTrash godoc
+// @Summary Корзина
+// @Description Возвращает страницу удаленных заметок текущего пользователя, начиная с удаленных последними. Заметки хранятся в корзине ограниченное время, после чего удаляются безвозвратно.
+// @Tags trash
+// @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 20, не более 100)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param order query string false "Направление сортировки по дате удаления" Enums(asc, desc) default(desc)
+// @Security ApiKeyAuth
+// @Success 200 {array} models.TrashedNote
+// @Header 200 {integer} X-Total-Count "Количество заметок в корзине"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /notes/trash [get]
+func (h *NoteHandler) GetTrash(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+
+	params, err := parseListParams(c, trashSortColumns, "deleted_at")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query := withRequest(c, h.db).Unscoped().Model(&models.Note{}).Where("notes.user_id = ? AND notes.deleted_at IS NOT NULL", userID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении корзины"})
+		return
+	}
+
+	notes := []models.Note{}
+	if err := paginate(query, params, trashSortColumns, "notes").Preload("Tags").Find(&notes).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при получении корзины"})
+		return
+	}
+
+	notes, nextCursor := notePage(notes, params)
+
+	trashed := make([]models.TrashedNote, len(notes))
+	for i, note := range notes {
+		trashed[i] = models.TrashedNote{Note: note, DeletedAt: note.DeletedAt.Time}
+	}
+	setPaginationHeaders(c, total, nextCursor)
+	c.JSON(http.StatusOK, trashed)
+}
+
+// findTrashedNote загружает удаленную заметку текущего пользователя. При ошибке ответ уже отправлен.
+func (h *NoteHandler) findTrashedNote(c *gin.Context, userID uint) (models.Note, bool) {
+	id, ok := parseNoteID(c)
+	if !ok {
+		return models.Note{}, false
+	}
+	note, err := h.notes.GetTrashed(c.Request.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNoteNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "Заметка не найдена в корзине"})
+			return note, false
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Ошибка при поиске заметки"})
+		return note, false
+	}
+	return note, true
+}
+
+// RestoreNote godoc
+// @Summary Восстановить заметку из корзины
+// @Description Возвращает удаленную заметку. Если её блокнот тоже удален, заметка восстанавливается в корень.
+// @Tags trash
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.NoteSwagger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /notes/{id}/restore [post]
+func (h *NoteHandler) RestoreNote(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	note, ok := h.findTrashedNote(c, userID)
+	if !ok {
+		return
+	}
+
+	note, err := h.notes.Restore(c.Request.Context(), note)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Не удалось восстановить заметку"})
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
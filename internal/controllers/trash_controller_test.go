@@ -0,0 +1,133 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/trash"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashController(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
+	r.GET("/notes/trash", noteHandler.GetTrash)
+	r.GET("/notes/:id", noteHandler.GetNote)
+	r.DELETE("/notes/:id", noteHandler.DeleteNote)
+	r.POST("/notes/:id/restore", noteHandler.RestoreNote)
+
+	token, userID := registerAndLoginUser(t, testDB, "testuser_trash", "trash@example.com", "password123")
+	token2, _ := registerAndLoginUser(t, testDB, "anotheruser_trash", "another_trash@example.com", "password123")
+
+	notePath := func(id uint) string {
+		return "/notes/" + strconv.FormatUint(uint64(id), 10)
+	}
+	listTrash := func(t *testing.T) []models.TrashedNote {
+		w := doRequest(r, http.MethodGet, "/notes/trash", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var notes []models.TrashedNote
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notes))
+		return notes
+	}
+
+	notebook := models.Notebook{Name: "Временный", UserID: userID}
+	assert.NoError(t, testDB.Create(&notebook).Error)
+	kept := models.Note{Title: "Нужная", Content: "Контент", UserID: userID, NotebookID: &notebook.ID}
+	discarded := models.Note{Title: "Ненужная", Content: "Контент", UserID: userID}
+	assert.NoError(t, testDB.Create(&kept).Error)
+	assert.NoError(t, testDB.Create(&discarded).Error)
+
+	t.Run("GetTrash - Lists deleted notes", func(t *testing.T) {
+		t.Log("Запуск: GetTrash - Удаленные заметки попадают в корзину")
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, notePath(kept.ID), token, nil).Code)
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodDelete, notePath(discarded.ID), token, nil).Code)
+
+		notes := listTrash(t)
+		if assert.Len(t, notes, 2) {
+			assert.Equal(t, "Ненужная", notes[0].Title, "Последняя удаленная заметка идет первой")
+			assert.False(t, notes[0].DeletedAt.IsZero(), "Должна быть указана дата удаления")
+		}
+
+		w := doRequest(r, http.MethodGet, "/notes/trash", token2, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String(), "Корзина другого пользователя не видна")
+	})
+
+	t.Run("RestoreNote - Successful", func(t *testing.T) {
+		t.Log("Запуск: RestoreNote - Восстановление из корзины")
+		// Блокнот заметки удален, поэтому она должна вернуться в корень
+		assert.NoError(t, testDB.Delete(&notebook).Error)
+
+		w := doRequest(r, http.MethodPost, notePath(kept.ID)+"/restore", token2, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, "Чужую заметку нельзя восстановить")
+
+		w = doRequest(r, http.MethodPost, notePath(kept.ID)+"/restore", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var restored models.Note
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+		assert.Nil(t, restored.NotebookID)
+
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, notePath(kept.ID), token, nil).Code)
+		assert.Len(t, listTrash(t), 1)
+
+		w = doRequest(r, http.MethodPost, notePath(kept.ID)+"/restore", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, "Заметку не из корзины восстановить нельзя")
+	})
+
+	t.Run("DeleteNote - Permanent", func(t *testing.T) {
+		t.Log("Запуск: DeleteNote - Безвозвратное удаление")
+		assert.NoError(t, testDB.Create(&models.NoteRevision{NoteID: discarded.ID, Revision: 1, Title: "Ненужная", UserID: userID}).Error)
+
+		w := doRequest(r, http.MethodDelete, notePath(discarded.ID)+"?permanent=yes", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Значение permanent проверяется")
+		var count int64
+		testDB.Unscoped().Model(&models.Note{}).Where("id = ?", discarded.ID).Count(&count)
+		assert.Equal(t, int64(1), count, "При неверном параметре заметка остается в корзине")
+
+		w = doRequest(r, http.MethodDelete, notePath(discarded.ID)+"?permanent=true", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Заметка удалена безвозвратно")
+
+		testDB.Unscoped().Model(&models.Note{}).Where("id = ?", discarded.ID).Count(&count)
+		assert.Zero(t, count, "Заметки не должно остаться даже в корзине")
+		testDB.Model(&models.NoteRevision{}).Where("note_id = ?", discarded.ID).Count(&count)
+		assert.Zero(t, count, "История правок удаляется вместе с заметкой")
+		assert.Empty(t, listTrash(t))
+
+		w = doRequest(r, http.MethodDelete, notePath(discarded.ID)+"?permanent=true", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Purge - Removes expired notes", func(t *testing.T) {
+		t.Log("Запуск: Purge - Очистка корзины по сроку хранения")
+		old := models.Note{Title: "Давно удалена", Content: "Контент", UserID: userID}
+		recent := models.Note{Title: "Недавно удалена", Content: "Контент", UserID: userID}
+		assert.NoError(t, testDB.Create(&old).Error)
+		assert.NoError(t, testDB.Create(&recent).Error)
+		assert.NoError(t, testDB.Delete(&recent).Error)
+		assert.NoError(t, testDB.Delete(&old).Error)
+		assert.NoError(t, testDB.Unscoped().Model(&old).Update("deleted_at", time.Now().Add(-40*24*time.Hour)).Error)
+
+		purged, err := trash.Purge(testDB, time.Now().Add(-30*24*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		notes := listTrash(t)
+		if assert.Len(t, notes, 1) {
+			assert.Equal(t, "Недавно удалена", notes[0].Title)
+		}
+	})
+}
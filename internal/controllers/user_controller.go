@@ -1,123 +1,125 @@
 package controllers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/internal/service"
 	"github.com/heebit/notes-api/models"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-// --- Вспомогательная функция для получения ID пользователя из токена ---
-// Она поможет избежать дублирования кода в каждом контроллере.
-func getValidatedUserID(c *gin.Context, paramID string) (uint, bool) {
-	// Получаем ID пользователя из токена. В middleware мы положили туда uint
-	userIDFromToken, exists := c.Get("user_id")
-	if !exists {
+// UserHandler обрабатывает запросы к профилю текущего пользователя.
+type UserHandler struct {
+	db    *gorm.DB
+	users *service.UserService
+	mail  mailer.Mailer
+	cfg   *config.Config
+}
+
+// NewUserHandler создает UserHandler. Правила работы с профилем выполняет users, письма
+// подтверждения нового email отправляются через mail со ссылками по настройкам cfg.
+func NewUserHandler(tx *gorm.DB, users *service.UserService, mail mailer.Mailer, cfg *config.Config) *UserHandler {
+	return &UserHandler{db: tx, users: users, mail: mail, cfg: cfg}
+}
+
+// respondUserError отправляет ответ об ошибке UserService.
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Нет доступа к профилю другого пользователя"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске пользователя"})
+	}
+}
+
+// resolveUserID возвращает ID пользователя, к профилю которого обращается запрос: из параметра пути
+// или текущего пользователя для маршрутов /users/me. Чужой профиль — 403, несуществующий — 404.
+// При ошибке ответ уже отправлен.
+func (h *UserHandler) resolveUserID(c *gin.Context) (uint, bool) {
+	currentID, ok := getUserIdFromContext(c)
+	if !ok {
 		return 0, false
 	}
-	
-	// Конвертируем ID из URL-параметра в uint64, а затем в uint
-	paramIDUint64, err := strconv.ParseUint(paramID, 10, 64)
+	param := c.Param("id")
+	if param == "" || param == "me" {
+		return currentID, true
+	}
+
+	id, err := strconv.ParseUint(param, 10, 32)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
 		return 0, false
 	}
-	paramIDUint := uint(paramIDUint64)
-
-	// Сравниваем ID из токена (uint) с ID из URL-параметра (uint)
-	// Здесь мы просто приводим userIDFromToken к типу uint
-	if userIDFromToken.(uint) != paramIDUint {
+	if err := h.users.CheckAccess(c.Request.Context(), currentID, uint(id)); err != nil {
+		respondUserError(c, err)
 		return 0, false
 	}
-
-	return paramIDUint, true
-}
-
-
-// GetUsers godoc
-// @Summary Получить всех пользователей
-// @Tags users
-// @Produce json
-// @Success 200 {array} models.UserSwagger
-// @Router /users [get]
-func GetUsers(c *gin.Context) {
-	var users []models.User
-	db.DB.Find(&users)
-
-	// Важно: не возвращаем поле Password!
-	var usersPublic []models.UserSwagger
-	for _, user := range users {
-		usersPublic = append(usersPublic, models.UserSwagger{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt.String(),
-			UpdatedAt: user.UpdatedAt.String(),
-		})
-	}
-	c.JSON(http.StatusOK, usersPublic)
+	return currentID, true
 }
 
 // GetUser godoc
 // @Summary Получить пользователя по ID
-// @Description Возвращает информацию о пользователе по ID. Доступно только для владельца токена.
+// @Description Возвращает информацию о пользователе по ID. Доступно только для владельца токена; вместо ID можно указать me.
 // @Tags users
-// @Param id path int true "User ID"
+// @Param id path string true "ID пользователя или me"
+// @Security ApiKeyAuth
 // @Success 200 {object} models.UserSwagger
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /users/{id} [get]
-func GetUser(c *gin.Context) {
-	id := c.Param("id")
-
-	// Проверяем, что запрашиваемый ID принадлежит текущему пользователю
-	if _, ok := getValidatedUserID(c, id); !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, ok := h.resolveUserID(c)
+	if !ok {
 		return
 	}
 
-	var user models.User
-	if err := db.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	user, err := h.users.Get(c.Request.Context(), id)
+	if err != nil {
+		respondUserError(c, err)
 		return
 	}
 
 	// Возвращаем только безопасную информацию
 	c.JSON(http.StatusOK, models.UserSwagger{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.String(),
-		UpdatedAt: user.UpdatedAt.String(),
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt.String(),
+		UpdatedAt:     user.UpdatedAt.String(),
 	})
 }
 
 // UpdateUser godoc
 // @Summary Обновить пользователя
-// @Description Обновляет информацию о пользователе по ID. Доступно только для владельца токена.
+// @Description Обновляет информацию о пользователе по ID. Доступно только для владельца токена; вместо ID можно указать me.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param id path int true "User ID"
+// @Param id path string true "ID пользователя или me"
 // @Param user body models.UpdateUserInput true "Данные пользователя для обновления"
+// @Security ApiKeyAuth
 // @Success 200 {object} models.UserSwagger
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /users/{id} [put]
-func UpdateUser(c *gin.Context) {
-	id := c.Param("id")
-
-	// Проверяем, что запрашиваемый ID принадлежит текущему пользователю
-	if _, ok := getValidatedUserID(c, id); !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
-		return
-	}
-
-	var user models.User
-	if err := db.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, ok := h.resolveUserID(c)
+	if !ok {
 		return
 	}
 
@@ -128,38 +130,64 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Обновляем поля, которые пришли в input, не трогая пароль
-	db.DB.Model(&user).Updates(&input)
+	user, emailChanged, err := h.users.Update(c.Request.Context(), id, input)
+	if errors.Is(err, service.ErrUserNotFound) {
+		respondUserError(c, err)
+		return
+	}
+	if errors.Is(err, service.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Пользователь с таким именем или email уже существует"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось обновить пользователя"})
+		return
+	}
+
+	// Новый адрес нужно подтвердить заново
+	if emailChanged {
+		if err := sendVerificationEmail(c.Request.Context(), withRequest(c, h.db), h.mail, h.cfg, &user); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Не удалось отправить письмо подтверждения", "recipient_id", user.ID, "error", err)
+		}
+	}
 
 	// Возвращаем обновленные данные, исключая пароль
 	c.JSON(http.StatusOK, models.UserSwagger{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.String(),
-		UpdatedAt: user.UpdatedAt.String(),
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt.String(),
+		UpdatedAt:     user.UpdatedAt.String(),
 	})
 }
 
 // DeleteUser godoc
 // @Summary Удалить пользователя
-// @Description Удаляет пользователя по ID. Доступно только для владельца токена.
+// @Description Удаляет пользователя по ID и завершает все его сессии. Доступно только для владельца токена; вместо ID можно указать me.
 // @Tags users
-// @Param id path int true "User ID"
+// @Param id path string true "ID пользователя или me"
+// @Security ApiKeyAuth
 // @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /users/{id} [delete]
-func DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-
-	// Проверяем, что запрашиваемый ID принадлежит текущему пользователю
-	if _, ok := getValidatedUserID(c, id); !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, ok := h.resolveUserID(c)
+	if !ok {
 		return
 	}
 
-	result := db.DB.Delete(&models.User{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	err := h.users.Delete(c.Request.Context(), id)
+	if errors.Is(err, service.ErrUserNotFound) {
+		respondUserError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось удалить пользователя"})
 		return
 	}
 
@@ -168,16 +196,17 @@ func DeleteUser(c *gin.Context) {
 
 // ChangePassword godoc
 // @Summary Сменить пароль
-// @Description Смена пароля для текущего авторизованного пользователя
+// @Description Смена пароля для текущего авторизованного пользователя. Refresh-токены всех сессий пользователя отзываются.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param password body models.ChangePasswordInput true "Старый и новый пароли"
+// @Security ApiKeyAuth
 // @Success 200 {object} models.MessageResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Router /users/me/password [put]
-func ChangePassword(c *gin.Context) {
+func (h *UserHandler) ChangePassword(c *gin.Context) {
 	userIDFromToken, exists := c.Get("user_id")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
@@ -190,28 +219,19 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// 1. Найти пользователя по ID
-	 var user models.User
-    if err := db.DB.First(&user, userIDFromToken.(uint)).Error; err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
-        return
-    }
-
-	// 2. Проверить старый пароль
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.OldPassword)); err != nil {
+	// Старый пароль проверяется перед сменой
+	err := h.users.ChangePassword(c.Request.Context(), userIDFromToken.(uint), input.OldPassword, input.NewPassword)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	case errors.Is(err, service.ErrWrongPassword):
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный старый пароль"})
 		return
-	}
-
-	// 3. Захешировать новый пароль
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось захешировать пароль"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось изменить пароль"})
 		return
 	}
 
-	// 4. Обновить пароль в БД
-	db.DB.Model(&user).Update("password", string(hashedPassword))
-
 	c.JSON(http.StatusOK, gin.H{"message": "Пароль успешно изменен"})
 }
@@ -7,9 +7,9 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/heebit/notes-api/internal/controllers"
 	"github.com/heebit/notes-api/middleware"
 	"github.com/heebit/notes-api/models"
 	"github.com/stretchr/testify/assert"
@@ -18,7 +18,8 @@ import (
 )
 
 func TestUserController(t *testing.T) {
-	testDB := setupTestDB()
+	testDB := setupTestDB(t)
+	userHandler := newUserHandler(testDB)
 	defer func() {
 		sqlDB, _ := testDB.DB()
 		sqlDB.Close()
@@ -26,13 +27,19 @@ func TestUserController(t *testing.T) {
 
 	// Инициализируем роутер с middleware
 	r := gin.Default()
+	authHandler := newAuthHandler(testDB)
+	r.POST("/auth/login", authHandler.Login)
+	r.POST("/auth/refresh", authHandler.Refresh)
 	userRoutes := r.Group("/users")
-	userRoutes.Use(middleware.AuthMiddleware())
+	userRoutes.Use(middleware.AuthMiddleware(testDB, testConfig().JWT))
 	{
-		userRoutes.GET("/:id", controllers.GetUser)
-		userRoutes.PUT("/:id", controllers.UpdateUser)
-		userRoutes.DELETE("/:id", controllers.DeleteUser)
-		userRoutes.PUT("/me/password", controllers.ChangePassword)
+		userRoutes.GET("/me", userHandler.GetUser)
+		userRoutes.PUT("/me", userHandler.UpdateUser)
+		userRoutes.DELETE("/me", userHandler.DeleteUser)
+		userRoutes.GET("/:id", userHandler.GetUser)
+		userRoutes.PUT("/:id", userHandler.UpdateUser)
+		userRoutes.DELETE("/:id", userHandler.DeleteUser)
+		userRoutes.PUT("/me/password", userHandler.ChangePassword)
 	}
 
 	// Создаем двух тестовых пользователей
@@ -73,8 +80,37 @@ func TestUserController(t *testing.T) {
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Нет доступа к профилю другого пользователя")
+
+		req, _ = http.NewRequest(http.MethodGet, "/users/999999", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
 		assert.Equal(t, http.StatusNotFound, w.Code)
 		assert.Contains(t, w.Body.String(), "Пользователь не найден")
+
+		req, _ = http.NewRequest(http.MethodGet, "/users/abc", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("GetUser - Me", func(t *testing.T) {
+		t.Log("Запуск: GetUser - Профиль текущего пользователя по /users/me")
+		req, _ := http.NewRequest(http.MethodGet, "/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var user models.UserSwagger
+		json.Unmarshal(w.Body.Bytes(), &user)
+		assert.Equal(t, userID, user.ID)
+		assert.Equal(t, models.RoleUser, user.Role)
 	})
 
 	t.Run("UpdateUser - Successful", func(t *testing.T) {
@@ -115,12 +151,68 @@ func TestUserController(t *testing.T) {
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusNotFound, w.Code)
-		assert.Contains(t, w.Body.String(), "Пользователь не найден")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var user2 models.User
+		testDB.First(&user2, userID2)
+		assert.Equal(t, "user2", user2.Username, "Чужой профиль не изменился")
+	})
+
+	t.Run("UpdateUser - Me", func(t *testing.T) {
+		t.Log("Запуск: UpdateUser - Обновление профиля по /users/me")
+		jsonValue, _ := json.Marshal(models.UpdateUserInput{Username: "me_user1"})
+		req, _ := http.NewRequest(http.MethodPut, "/users/me", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var user models.UserSwagger
+		json.Unmarshal(w.Body.Bytes(), &user)
+		assert.Equal(t, userID, user.ID)
+		assert.Equal(t, "me_user1", user.Username)
+	})
+
+	t.Run("UpdateUser - Username or Email Taken", func(t *testing.T) {
+		t.Log("Запуск: UpdateUser - Имя или email уже заняты другим пользователем")
+		testDB.Model(&models.User{}).Where("id = ?", userID).Update("email_verified_at", time.Now())
+
+		for _, input := range []models.UpdateUserInput{{Username: "user2"}, {Email: "user2@example.com"}} {
+			w := doRequest(r, http.MethodPut, "/users/me", token, input)
+			assert.Equal(t, http.StatusConflict, w.Code)
+			assert.Contains(t, w.Body.String(), "уже существует")
+		}
+
+		var dbUser models.User
+		testDB.First(&dbUser, userID)
+		assert.Equal(t, "me_user1", dbUser.Username)
+		assert.Equal(t, "updated_user1@example.com", dbUser.Email)
+		assert.NotNil(t, dbUser.EmailVerifiedAt, "Отметка о подтверждении не снята")
+	})
+
+	t.Run("UpdateUser - Email Change Resets Verification", func(t *testing.T) {
+		t.Log("Запуск: UpdateUser - Смена email снимает отметку о подтверждении")
+		w := doRequest(r, http.MethodPut, "/users/me", token, models.UpdateUserInput{Email: "user1@example.com"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var user models.UserSwagger
+		json.Unmarshal(w.Body.Bytes(), &user)
+		assert.Equal(t, "user1@example.com", user.Email)
+		assert.False(t, user.EmailVerified)
+
+		var dbUser models.User
+		testDB.First(&dbUser, userID)
+		assert.Equal(t, "user1@example.com", dbUser.Email)
+		assert.Nil(t, dbUser.EmailVerifiedAt)
 	})
 
 	t.Run("ChangePassword - Successful", func(t *testing.T) {
 		t.Log("Запуск: ChangePassword - Успешная смена пароля")
+		w := doRequest(r, http.MethodPost, "/auth/login", "", models.LoginInput{Identifier: "me_user1", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var session models.TokenPair
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+
 		changePasswordInput := models.ChangePasswordInput{
 			OldPassword: "password123",
 			NewPassword: "new_password_strong",
@@ -129,12 +221,15 @@ func TestUserController(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodPut, "/users/me/password", bytes.NewBuffer(jsonValue))
 		req.Header.Set("Authorization", "Bearer "+token)
 		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
+		w = httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "Пароль успешно изменен")
 
+		w = doRequest(r, http.MethodPost, "/auth/refresh", "", models.RefreshInput{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "После смены пароля сессии завершаются")
+
 		// Проверяем, что пароль в БД действительно изменился
 		var dbUser models.User
 		testDB.First(&dbUser, userID)
@@ -181,14 +276,35 @@ func TestUserController(t *testing.T) {
 		assert.Equal(t, gorm.ErrRecordNotFound, result.Error)
 	})
 
-	t.Run("DeleteUser - Not Found or Not Owned", func(t *testing.T) {
+	t.Run("DeleteUser - Not Owned", func(t *testing.T) {
 		t.Log("Запуск: DeleteUser - Попытка удалить профиль другого пользователя")
+		token3, _ := registerAndLoginUser(t, testDB, "user3", "user3@example.com", "password123")
 		req, _ := http.NewRequest(http.MethodDelete, "/users/"+strconv.FormatUint(uint64(userID2), 10), nil)
-		req.Header.Set("Authorization", "Bearer "+token) // Используем токен уже удаленного пользователя
+		req.Header.Set("Authorization", "Bearer "+token3)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusNotFound, w.Code)
-		assert.Contains(t, w.Body.String(), "Пользователь не найден")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// Токен удаленного пользователя больше не принимается
+		req, _ = http.NewRequest(http.MethodDelete, "/users/"+strconv.FormatUint(uint64(userID2), 10), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("DeleteUser - Me", func(t *testing.T) {
+		t.Log("Запуск: DeleteUser - Удаление профиля по /users/me")
+		token4, userID4 := registerAndLoginUser(t, testDB, "user4", "user4@example.com", "password123")
+		req, _ := http.NewRequest(http.MethodDelete, "/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token4)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		result := testDB.First(&models.User{}, userID4)
+		assert.Equal(t, gorm.ErrRecordNotFound, result.Error)
 	})
 }
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
+)
+
+// sendVerificationEmail отправляет пользователю подписанную ссылку подтверждения email
+// и запоминает время отправки для ограничения повторных писем.
+func sendVerificationEmail(ctx context.Context, tx *gorm.DB, mail mailer.Mailer, cfg *config.Config, user *models.User) error {
+	token, err := utils.GenerateEmailVerificationToken(cfg.JWT, cfg.Auth.EmailVerificationTTL, user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+	link := cfg.App.BaseURL + "/auth/verify?token=" + url.QueryEscape(token)
+	err = mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить email, перейдите по ссылке:\n%s\n\nСсылка действует %s.\n",
+			user.Username, link, cfg.Auth.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.VerificationSentAt = &now
+	return tx.Model(user).Update("verification_sent_at", now).Error
+}
+
+// VerifyEmail godoc
+// @Summary Подтверждение email
+// @Description Подтверждает email по подписанной ссылке из письма
+// @Tags auth
+// @Produce json
+// @Param token query string true "Токен из письма"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/verify [get]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	userID, email, err := utils.ParseEmailVerificationToken(h.cfg.JWT, c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ссылка подтверждения недействительна или устарела"})
+		return
+	}
+
+	var user models.User
+	if err := withRequest(c, h.db).First(&user, userID).Error; err != nil || user.Email != email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ссылка подтверждения недействительна или устарела"})
+		return
+	}
+	if user.EmailVerifiedAt == nil {
+		if err := withRequest(c, h.db).Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось подтвердить email"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email подтвержден"})
+}
+
+// ResendVerification godoc
+// @Summary Повторная отправка письма подтверждения
+// @Description Отправляет новую ссылку подтверждения email. Письма можно запрашивать не чаще раза в EMAIL_VERIFICATION_RESEND_INTERVAL (по умолчанию минута).
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Router /auth/verify/resend [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, ok := getUserIdFromContext(c)
+	if !ok {
+		return
+	}
+	var user models.User
+	if err := withRequest(c, h.db).First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email уже подтвержден"})
+		return
+	}
+
+	interval := h.cfg.Auth.EmailVerificationResendInterval
+	if user.VerificationSentAt != nil {
+		if wait := time.Until(user.VerificationSentAt.Add(interval)); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Письмо уже отправлено, повторите попытку позже"})
+			return
+		}
+	}
+
+	if err := sendVerificationEmail(c.Request.Context(), withRequest(c, h.db), h.mail, h.cfg, &user); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Не удалось отправить письмо подтверждения", "recipient_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось отправить письмо"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Письмо с подтверждением отправлено"})
+}
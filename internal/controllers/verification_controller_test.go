@@ -0,0 +1,104 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerification(t *testing.T) {
+	testDB := setupTestDB(t)
+	noteHandler := newNoteHandler(testDB)
+	authHandler := newAuthHandler(testDB)
+	defer func() {
+		sqlDB, _ := testDB.DB()
+		sqlDB.Close()
+	}()
+
+	r := gin.Default()
+	r.POST("/auth/register", authHandler.Register)
+	r.GET("/auth/verify", authHandler.VerifyEmail)
+	r.POST("/auth/verify/resend", middleware.AuthMiddleware(testDB, testConfig().JWT), authHandler.ResendVerification)
+	r.GET("/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), middleware.RequireVerifiedEmail(false), noteHandler.GetNotes)
+	r.GET("/verified/notes", middleware.AuthMiddleware(testDB, testConfig().JWT), middleware.RequireVerifiedEmail(true), noteHandler.GetNotes)
+
+	linkPattern := regexp.MustCompile(`/auth/verify\?token=(\S+)`)
+	lastVerifyPath := func(t *testing.T, email string) string {
+		var message models.OutboxEmail
+		assert.NoError(t, testDB.Where("\"to\" = ?", email).Order("id DESC").First(&message).Error)
+		match := linkPattern.FindStringSubmatch(message.Body)
+		if !assert.Len(t, match, 2, "Письмо должно содержать ссылку подтверждения") {
+			return ""
+		}
+		return "/auth/verify?token=" + match[1]
+	}
+
+	t.Run("Register - Sends verification email", func(t *testing.T) {
+		t.Log("Запуск: Register - Новый пользователь не подтвержден")
+		w := doRequest(r, http.MethodPost, "/auth/register", "", models.User{Username: "testuser_verify", Email: "verify@example.com", Password: "password123"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var user models.User
+		assert.NoError(t, testDB.Where("username = ?", "testuser_verify").First(&user).Error)
+		assert.Nil(t, user.EmailVerifiedAt)
+
+		w = doRequest(r, http.MethodGet, lastVerifyPath(t, "verify@example.com"), "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, testDB.First(&user, user.ID).Error)
+		assert.NotNil(t, user.EmailVerifiedAt)
+	})
+
+	t.Run("VerifyEmail - Invalid tokens", func(t *testing.T) {
+		t.Log("Запуск: VerifyEmail - Подделанные и устаревшие ссылки")
+		assert.Equal(t, http.StatusBadRequest, doRequest(r, http.MethodGet, "/auth/verify?token=garbage", "", nil).Code)
+
+		_, userID := registerAndLoginUser(t, testDB, "testuser_verify_old", "verify_old@example.com", "password123")
+		token, err := utils.GenerateEmailVerificationToken(testConfig().JWT, time.Hour, userID, "previous@example.com")
+		assert.NoError(t, err)
+		w := doRequest(r, http.MethodGet, "/auth/verify?token="+url.QueryEscape(token), "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Ссылка для прежнего адреса не подтверждает новый")
+
+		accessToken, _, err := utils.GenerateAccessToken(testConfig().JWT, userID)
+		assert.NoError(t, err)
+		w = doRequest(r, http.MethodGet, "/auth/verify?token="+url.QueryEscape(accessToken), "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Access-токен не подходит для подтверждения")
+	})
+
+	t.Run("ResendVerification - Throttling", func(t *testing.T) {
+		t.Log("Запуск: ResendVerification - Ограничение частоты писем")
+		token, userID := registerAndLoginUser(t, testDB, "testuser_resend", "resend@example.com", "password123")
+
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodPost, "/auth/verify/resend", token, nil).Code)
+		w := doRequest(r, http.MethodPost, "/auth/verify/resend", token, nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		testDB.Model(&models.User{}).Where("id = ?", userID).Update("verification_sent_at", time.Now().Add(-time.Hour))
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodPost, "/auth/verify/resend", token, nil).Code)
+
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, lastVerifyPath(t, "resend@example.com"), "", nil).Code)
+		w = doRequest(r, http.MethodPost, "/auth/verify/resend", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Подтвержденному пользователю письмо не отправляется")
+	})
+
+	t.Run("RequireVerifiedEmail - Config switch", func(t *testing.T) {
+		t.Log("Запуск: RequireVerifiedEmail - Доступ к заметкам без подтверждения email")
+		token, userID := registerAndLoginUser(t, testDB, "testuser_unverified", "unverified@example.com", "password123")
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/notes", token, nil).Code, "Проверка выключена")
+
+		w := doRequest(r, http.MethodGet, "/verified/notes", token, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Подтвердите email")
+
+		testDB.Model(&models.User{}).Where("id = ?", userID).Update("email_verified_at", time.Now())
+		assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/verified/notes", token, nil).Code)
+	})
+}
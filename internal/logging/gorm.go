@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold — запросы дольше этого времени записываются с уровнем warn.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger передает журнал GORM в slog с контекстом запроса. Ошибки запросов пишутся с уровнем error,
+// медленные запросы — warn, остальные — debug. Параметры в текст SQL не подставляются: в них бывают
+// хеши паролей и токены.
+type GormLogger struct {
+	level logger.LogLevel
+}
+
+// NewGormLogger создает GormLogger, пишущий все записи, включая отладочные.
+func NewGormLogger() *GormLogger {
+	return &GormLogger{level: logger.Info}
+}
+
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &GormLogger{level: level}
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	switch {
+	// Отсутствие записи — обычный результат поиска, а не ошибка
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		level = slog.LevelError
+	case elapsed > slowQueryThreshold && l.level >= logger.Warn:
+		level = slog.LevelWarn
+	case l.level < logger.Info:
+		return
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds()}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	message := "SQL-запрос"
+	if level == slog.LevelWarn {
+		message = "Медленный SQL-запрос"
+	}
+	slog.Log(ctx, level, message, attrs...)
+}
+
+// ParamsFilter убирает параметры запроса из журнала.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
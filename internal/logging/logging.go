@@ -0,0 +1,89 @@
+// Package logging настраивает журнал приложения на основе log/slog. Записи, сделанные с контекстом
+// запроса (slog.InfoContext и т. п.), получают request_id и user_id, а при включенной трассировке —
+// trace_id и span_id; значения полей с паролями и токенами заменяются на [REDACTED].
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"github.com/heebit/notes-api/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New создает журнал, пишущий в w в формате и с уровнем из cfg.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: cfg.Level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup делает журнал с настройками cfg, пишущий в stdout, журналом по умолчанию. Через него же
+// идут записи стандартного пакета log.
+func Setup(cfg config.LogConfig) {
+	slog.SetDefault(New(cfg, os.Stdout))
+}
+
+// requestInfo — данные запроса для записей журнала. user_id становится известен только после
+// авторизации, поэтому хранится изменяемым значением.
+type requestInfo struct {
+	id     string
+	userID atomic.Uint64
+}
+
+type requestInfoKey struct{}
+
+// WithRequestID возвращает контекст запроса с идентификатором id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, &requestInfo{id: id})
+}
+
+// RequestID возвращает идентификатор запроса из ctx или пустую строку.
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID запоминает пользователя запроса: дальнейшие записи с этим контекстом получат user_id.
+// Вне запроса (без WithRequestID) ничего не делает.
+func SetUserID(ctx context.Context, userID uint) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID.Store(uint64(userID))
+	}
+}
+
+// contextHandler добавляет к записям request_id, user_id и идентификаторы трассы из контекста.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		record.AddAttrs(slog.String("request_id", info.id))
+		if userID := info.userID.Load(); userID != 0 {
+			record.AddAttrs(slog.Uint64("user_id", userID))
+		}
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Redacted заменяет в журнале значения секретных полей.
+const Redacted = "[REDACTED]"
+
+// Sensitive сообщает, хранит ли поле с именем key секрет: пароль, токен, секрет TOTP или код
+// подтверждения. Регистр и разделители в имени не важны (newPassword, refresh_token, X-Share-Password).
+func Sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range []string{"password", "token", "secret", "authorization", "cookie"} {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return key == "code" || strings.HasSuffix(key, "_code") || strings.HasSuffix(key, "codes")
+}
+
+// RedactPath заменяет на Redacted сегменты пути запроса path, которые в шаблоне маршрута route
+// соответствуют секретным параметрам, например :token в /public/:token.
+func RedactPath(route, path string) string {
+	if !strings.ContainsAny(route, ":*") {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, part := range strings.Split(route, "/") {
+		if i >= len(segments) {
+			break
+		}
+		if part == "" || (part[0] != ':' && part[0] != '*') || !Sensitive(part[1:]) {
+			continue
+		}
+		if part[0] == '*' {
+			// *name захватывает остаток пути вместе с вложенными сегментами
+			return strings.Join(append(segments[:i], Redacted), "/")
+		}
+		segments[i] = Redacted
+	}
+	return strings.Join(segments, "/")
+}
+
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	if Sensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// RedactBody возвращает тело запроса для журнала: в JSON и формах значения секретных полей
+// заменяются на [REDACTED], тела других типов не выводятся.
+func RedactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return fmt.Sprintf("[некорректный JSON, %d байт]", len(body))
+		}
+		redacted, _ := json.Marshal(redactValue(value))
+		return string(redacted)
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[некорректная форма, %d байт]", len(body))
+		}
+		for key := range form {
+			if Sensitive(key) {
+				form[key] = []string{Redacted}
+			}
+		}
+		return form.Encode()
+	}
+	return fmt.Sprintf("[%s, %d байт]", mediaType, len(body))
+}
+
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if Sensitive(key) {
+				value[key] = Redacted
+			} else {
+				value[key] = redactValue(item)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,37 @@
+// Package mailer отправляет служебные письма. В production используется SMTP,
+// в разработке и тестах письма складываются в таблицу outbox_emails.
+package mailer
+
+import (
+	"context"
+
+	"github.com/heebit/notes-api/config"
+	"gorm.io/gorm"
+)
+
+// Message — письмо с текстовым телом.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New выбирает транспорт по настройкам: при заданном SMTP_HOST письма уходят через SMTP,
+// иначе сохраняются в outbox базы данных.
+func New(cfg config.MailConfig, tx *gorm.DB) Mailer {
+	if cfg.SMTPHost == "" {
+		return NewOutbox(tx)
+	}
+	return &SMTPMailer{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.From,
+	}
+}
@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// OutboxMailer сохраняет письма в таблицу outbox_emails вместо отправки.
+// Подходит для разработки и тестов: письма можно прочитать прямо из базы.
+type OutboxMailer struct {
+	db *gorm.DB
+}
+
+func NewOutbox(tx *gorm.DB) *OutboxMailer {
+	return &OutboxMailer{db: tx}
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	return m.db.WithContext(ctx).Create(&models.OutboxEmail{
+		To:      msg.To,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	}).Error
+}
@@ -0,0 +1,86 @@
+// Package metrics собирает метрики приложения в формате Prometheus: HTTP-запросы по шаблонам маршрутов,
+// состояние пула соединений с базой, время хеширования паролей, попытки входа и показатели заметок.
+// Метрики регистрируются в собственном реестре Registry, который отдает Handler.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry — реестр метрик приложения. Кроме метрик пакета в нем есть метрики рантайма Go и процесса.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Число обработанных HTTP-запросов по методу, шаблону маршрута и статусу ответа.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Время обработки HTTP-запросов по методу, шаблону маршрута и статусу ответа.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	passwordHashDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "password_hash_duration_seconds",
+		Help: "Время хеширования (hash) и проверки (compare) паролей bcrypt.",
+		// bcrypt намеренно медленный: от десятков миллисекунд до секунды в зависимости от cost
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation"})
+
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Число попыток входа по результату: success, wrong_password, wrong_code, locked_out.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		passwordHashDuration,
+		loginAttempts,
+		notesTotal,
+		notesCreatedPerMinute,
+		usersTotal,
+	)
+}
+
+// Handler отдает метрики реестра Registry в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB добавляет метрики пула соединений sqlDB (go_sql_*, с меткой db_name=name), снимаемые
+// из sqlDB.Stats() при каждом опросе.
+func RegisterDB(sqlDB *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(sqlDB, name))
+}
+
+// ObserveRequest учитывает обработанный HTTP-запрос. route — шаблон маршрута (например, /notes/:id),
+// а не фактический путь, чтобы число временных рядов не зависело от ID в URL.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObservePasswordHash учитывает время операции bcrypt, начатой в start: "hash" или "compare".
+func ObservePasswordHash(operation string, start time.Time) {
+	passwordHashDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// LoginAttempt учитывает попытку входа с результатом result (models.LoginResult*).
+func LoginAttempt(result string) {
+	loginAttempts.WithLabelValues(result).Inc()
+}
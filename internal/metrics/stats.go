@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/heebit/notes-api/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+var (
+	notesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "notes_total",
+		Help: "Число заметок, не считая корзины.",
+	})
+
+	notesCreatedPerMinute = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "notes_created_per_minute",
+		Help: "Число заметок, созданных за последнюю минуту.",
+	})
+
+	usersTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "users_total",
+		Help: "Число зарегистрированных пользователей.",
+	})
+)
+
+// UpdateStats пересчитывает показатели заметок и пользователей по базе данных на момент now.
+func UpdateStats(tx *gorm.DB, now time.Time) error {
+	var notes, created, users int64
+	if err := tx.Model(&models.Note{}).Count(&notes).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Note{}).Where("created_at >= ?", now.Add(-time.Minute)).Count(&created).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.User{}).Count(&users).Error; err != nil {
+		return err
+	}
+	notesTotal.Set(float64(notes))
+	notesCreatedPerMinute.Set(float64(created))
+	usersTotal.Set(float64(users))
+	return nil
+}
+
+// RunStats раз в минуту обновляет показатели заметок и пользователей. Работает до отмены ctx.
+// Подсчет идет в фоне, а не при каждом опросе /metrics, чтобы частый опрос не нагружал базу.
+func RunStats(ctx context.Context, tx *gorm.DB) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if err := UpdateStats(tx, time.Now()); err != nil {
+			slog.Error("Ошибка обновления метрик", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// postgresLockID — ключ advisory-блокировки миграций в Postgres.
+const postgresLockID = 7_316_502_219
+
+// sqliteStaleLock — через сколько блокировка SQLite считается брошенной упавшим процессом.
+const sqliteStaleLock = 15 * time.Minute
+
+// lock не дает нескольким экземплярам приложения применять миграции одновременно и ждет,
+// пока блокировка освободится или будет отменен ctx.
+//
+// В Postgres используется сессионная advisory-блокировка: она снимается и при обрыве соединения.
+// В SQLite блокировкой служит строка таблицы schema_migrations_lock; если процесс упал,
+// не сняв её, строка удаляется по истечении sqliteStaleLock.
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if m.dialect == "postgres" {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresLockID); err != nil {
+			return nil, err
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", postgresLockID)
+		}, nil
+	}
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		locked_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations_lock WHERE locked_at < ?",
+			time.Now().UTC().Add(-sqliteStaleLock)); err != nil {
+			return nil, err
+		}
+		result, err := conn.ExecContext(ctx, "INSERT OR IGNORE INTO schema_migrations_lock (id, locked_at) VALUES (1, ?)",
+			time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := result.RowsAffected(); n == 1 {
+			return func() {
+				conn.ExecContext(context.Background(), "DELETE FROM schema_migrations_lock WHERE id = 1")
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Package migrate применяет SQL-миграции из пакета migrations и ведет их учет в таблице schema_migrations.
+// Несколько экземпляров приложения могут запускать миграции одновременно: их выполнение
+// защищено блокировкой (см. lock.go), так что каждую миграцию применяет ровно один экземпляр.
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration — одна миграция: версия и название берутся из имени файла <версия>_<название>.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+	// noTx — миграция помечена "-- +goose NO TRANSACTION" (например, CREATE INDEX CONCURRENTLY).
+	noTx bool
+}
+
+// Status — состояние миграции; AppliedAt пуст у еще не примененных.
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator применяет миграции одного диалекта к базе данных.
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New загружает миграции диалекта dialect ("postgres" или "sqlite") из каталога с тем же именем в fsys.
+func New(db *sql.DB, dialect string, fsys fs.FS) (*Migrator, error) {
+	if dialect != "postgres" && dialect != "sqlite" {
+		return nil, fmt.Errorf("миграции не поддерживаются для %s", dialect)
+	}
+	migrations, err := load(fsys, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Up применяет все еще не примененные миграции по возрастанию версии и возвращает их.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает последнюю примененную миграцию и возвращает её; nil — откатывать нечего.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		var last int64
+		for version := range done {
+			last = max(last, version)
+		}
+		if last == 0 {
+			return nil
+		}
+		for i := range m.migrations {
+			if m.migrations[i].Version == last {
+				reverted = &m.migrations[i]
+				return m.run(ctx, conn, *reverted, false)
+			}
+		}
+		return fmt.Errorf("файл примененной миграции %d не найден", last)
+	})
+	return reverted, err
+}
+
+// Status возвращает все известные миграции по возрастанию версии. Таблицу учета он не создает:
+// если её еще нет, все миграции считаются не примененными.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	done := map[int64]time.Time{}
+	if exists, err := m.tableExists(ctx, conn, "schema_migrations"); err != nil {
+		return nil, err
+	} else if exists {
+		if done, err = m.applied(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending возвращает число еще не примененных миграций.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// withLock берет блокировку миграций на выделенном соединении, создает таблицу учета
+// и выполняет fn на том же соединении.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	exists, err := m.tableExists(ctx, conn, "schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("не удалось создать schema_migrations: %w", err)
+	}
+	return m.importGoose(ctx, conn)
+}
+
+// importGoose переносит историю из goose_db_version, если база раньше мигрировалась утилитой goose,
+// чтобы уже примененные миграции не выполнялись повторно.
+func (m *Migrator) importGoose(ctx context.Context, conn *sql.Conn) error {
+	exists, err := m.tableExists(ctx, conn, "goose_db_version")
+	if err != nil || !exists {
+		return err
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT version_id, is_applied FROM goose_db_version ORDER BY id")
+	if err != nil {
+		return err
+	}
+	// Последняя запись по версии отражает её текущее состояние
+	state := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			rows.Close()
+			return err
+		}
+		state[version] = isApplied
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	names := map[int64]string{}
+	for _, migration := range m.migrations {
+		names[migration.Version] = migration.Name
+	}
+	for version, isApplied := range state {
+		if version == 0 || !isApplied {
+			continue
+		}
+		if err := m.record(ctx, conn, version, names[version]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if m.dialect == "postgres" {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	}
+	var count int
+	err := conn.QueryRowContext(ctx, query, table).Scan(&count)
+	return count > 0, err
+}
+
+// applied возвращает версии примененных миграций и время их применения.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (m *Migrator) record(ctx context.Context, ex execer, version int64, name string) error {
+	_, err := ex.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES ("+
+		m.placeholder(1)+", "+m.placeholder(2)+", "+m.placeholder(3)+")", version, name, time.Now().UTC())
+	return err
+}
+
+func (m *Migrator) forget(ctx context.Context, ex execer, version int64) error {
+	_, err := ex.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = "+m.placeholder(1), version)
+	return err
+}
+
+func (m *Migrator) placeholder(n int) string {
+	if m.dialect == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// run применяет (up) или откатывает миграцию вместе с записью в schema_migrations в одной транзакции.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	script, direction := migration.up, "up"
+	if !up {
+		script, direction = migration.down, "down"
+	}
+	apply := func(ex execer) error {
+		if strings.TrimSpace(script) != "" {
+			if _, err := ex.ExecContext(ctx, script); err != nil {
+				return err
+			}
+		}
+		if up {
+			return m.record(ctx, ex, migration.Version, migration.Name)
+		}
+		return m.forget(ctx, ex, migration.Version)
+	}
+
+	var err error
+	if migration.noTx {
+		err = apply(conn)
+	} else {
+		var tx *sql.Tx
+		if tx, err = conn.BeginTx(ctx, nil); err == nil {
+			if err = apply(tx); err != nil {
+				tx.Rollback()
+			} else {
+				err = tx.Commit()
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("миграция %d_%s (%s): %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := map[int64]string{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("версия %d повторяется в %s и %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		migration.Version, migration.Name = version, match[2]
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parse разбирает файл в формате goose на секции Up и Down. Аннотации StatementBegin/StatementEnd
+// допускаются, но не нужны: секция выполняется целиком одним запросом.
+func parse(data []byte) (Migration, error) {
+	var migration Migration
+	var up, down strings.Builder
+	var section *strings.Builder
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose "); ok {
+			switch strings.ToUpper(strings.TrimSpace(annotation)) {
+			case "UP":
+				section = &up
+			case "DOWN":
+				section = &down
+			case "NO TRANSACTION":
+				migration.noTx = true
+			case "STATEMENTBEGIN", "STATEMENTEND":
+			default:
+				return migration, fmt.Errorf("неизвестная аннотация %q", line)
+			}
+			continue
+		}
+		if section != nil {
+			section.WriteString(line)
+			section.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return migration, err
+	}
+	if strings.TrimSpace(up.String()) == "" {
+		return migration, errors.New("нет секции -- +goose Up")
+	}
+	migration.up, migration.down = up.String(), down.String()
+	return migration, nil
+}
+
+const template = `-- +goose Up
+
+
+-- +goose Down
+
+`
+
+// Create создает пустые файлы миграции name для обоих диалектов в dir/postgres и dir/sqlite
+// с версией по времени now и возвращает их пути.
+func Create(dir, name string, now time.Time) ([]string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !regexp.MustCompile(`^\w+$`).MatchString(name) {
+		return nil, fmt.Errorf("некорректное название миграции %q: допустимы буквы, цифры и _", name)
+	}
+	file := now.UTC().Format("20060102150405") + "_" + name + ".sql"
+
+	var paths []string
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		p := filepath.Join(dir, dialect, file)
+		if err := os.WriteFile(p, []byte(template), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
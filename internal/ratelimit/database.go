@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DBStore хранит корзины в таблице rate_limit_buckets, так что лимиты общие для всех экземпляров приложения.
+type DBStore struct {
+	db *gorm.DB
+}
+
+func NewDBStore(tx *gorm.DB) *DBStore {
+	return &DBStore{db: tx}
+}
+
+func (s *DBStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	var result Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RateLimitBucket{
+			Key:        key,
+			Tokens:     float64(limit.Requests),
+			RefilledAt: now,
+			FullAt:     now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// В PostgreSQL строка блокируется до конца транзакции, чтобы одновременные запросы
+		// не забрали один и тот же токен; SQLite и так выполняет записи последовательно
+		query := tx
+		if tx.Dialector.Name() == "postgres" {
+			query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var bucket models.RateLimitBucket
+		if err := query.Where("key = ?", key).First(&bucket).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = take(bucket.Tokens, bucket.RefilledAt, limit, now)
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"tokens":      tokens,
+			"refilled_at": now,
+			"full_at":     now.Add(result.Reset),
+		}).Error
+	})
+	return result, err
+}
+
+func (s *DBStore) Cleanup(ctx context.Context, now time.Time) error {
+	return s.db.WithContext(ctx).Where("full_at <= ?", now).Delete(&models.RateLimitBucket{}).Error
+}
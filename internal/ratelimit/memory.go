@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryBucket struct {
+	tokens     float64
+	refilledAt time.Time
+	fullAt     time.Time
+}
+
+// MemoryStore хранит корзины в памяти процесса. Подходит для одного экземпляра приложения.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]memoryBucket)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = memoryBucket{tokens: float64(limit.Requests), refilledAt: now}
+	}
+	tokens, result := take(bucket.tokens, bucket.refilledAt, limit, now)
+	s.buckets[key] = memoryBucket{tokens: tokens, refilledAt: now, fullAt: now.Add(result.Reset)}
+	return result, nil
+}
+
+func (s *MemoryStore) Cleanup(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, bucket := range s.buckets {
+		if !bucket.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
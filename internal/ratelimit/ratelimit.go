@@ -0,0 +1,93 @@
+// Package ratelimit ограничивает частоту запросов по алгоритму token bucket.
+// Состояние корзин хранится в Store: в памяти процесса или в базе данных, если экземпляров приложения несколько.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Limit — не более Requests запросов за Per. Корзина вмещает Requests токенов
+// и равномерно пополняется, так что допускаются короткие всплески.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// rate — скорость пополнения корзины в токенах в секунду.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result — состояние корзины после попытки забрать токен.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен; имеет смысл, если запрос отклонен.
+	RetryAfter time.Duration
+	// Reset — через сколько корзина снова заполнится полностью.
+	Reset time.Duration
+}
+
+// Store хранит корзины токенов.
+type Store interface {
+	// Take забирает токен из корзины key, создавая полную корзину при первом обращении.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Cleanup удаляет корзины, заполнившиеся к моменту now: они не отличаются от новых.
+	Cleanup(ctx context.Context, now time.Time) error
+}
+
+// NewStore выбирает хранилище по RATE_LIMIT_STORE: database — таблица rate_limit_buckets,
+// общая для всех экземпляров приложения, иначе — память процесса.
+func NewStore(kind string, tx *gorm.DB) Store {
+	if kind == "database" {
+		return NewDBStore(tx)
+	}
+	return NewMemoryStore()
+}
+
+// take пересчитывает корзину с tokens токенами, последний раз пополненную в refilledAt,
+// и пытается забрать из неё токен. Возвращает новое число токенов.
+func take(tokens float64, refilledAt time.Time, limit Limit, now time.Time) (float64, Result) {
+	capacity := float64(limit.Requests)
+	if elapsed := now.Sub(refilledAt); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed.Seconds()*limit.rate())
+	}
+
+	var result Result
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+	result.Remaining = int(tokens)
+	result.Reset = seconds((capacity - tokens) / limit.rate())
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RunCleanup раз в interval удаляет заполнившиеся корзины. Работает до отмены ctx.
+func RunCleanup(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := store.Cleanup(ctx, time.Now()); err != nil {
+			slog.Error("Ошибка очистки ограничений частоты запросов", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/heebit/notes-api/internal/trash"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// NoteUpdate описывает изменение заметки для NoteRepository.Update.
+type NoteUpdate struct {
+	// Revision — сохранить новое состояние заметки как ревизию от имени EditorID.
+	Revision bool
+	EditorID uint
+	// RestoredFrom — номер ревизии, из которой восстановлен текст.
+	RestoredFrom *int
+	// Tags — новые метки заметки; nil оставляет метки без изменений.
+	Tags []string
+}
+
+// NoteRepository хранит заметки вместе с метками и историей правок.
+type NoteRepository interface {
+	// FindByID загружает заметку с метками. withTrashed — искать и среди заметок в корзине.
+	FindByID(ctx context.Context, id uint, withTrashed bool) (models.Note, error)
+	// FindTrashed загружает заметку пользователя, находящуюся в корзине.
+	FindTrashed(ctx context.Context, id, userID uint) (models.Note, error)
+	// SharePermission возвращает уровень доступа, выданный пользователю к заметке, или пустую строку.
+	SharePermission(ctx context.Context, noteID, userID uint) (string, error)
+	// NotebookOwned сообщает, существует ли блокнот и принадлежит ли он пользователю.
+	NotebookOwned(ctx context.Context, userID, notebookID uint) (bool, error)
+
+	// Create сохраняет новую заметку, её первую ревизию и метки tags, создавая недостающие.
+	Create(ctx context.Context, note *models.Note, tags []string) error
+	// Update сохраняет заголовок и текст заметки вместе с ревизией и метками из update.
+	// Если у заметки, созданной до появления истории правок, еще нет ревизий, перед новой ревизией
+	// сохраняется ее исходное состояние.
+	Update(ctx context.Context, note *models.Note, update NoteUpdate) error
+	// Move переносит заметку в блокнот notebookID (nil — в корень).
+	Move(ctx context.Context, note *models.Note, notebookID *uint) error
+	// Trash перемещает заметку в корзину. Заметка, уже находящаяся в корзине, — ErrNotFound.
+	Trash(ctx context.Context, note models.Note) error
+	// Restore возвращает заметку из корзины в блокнот note.NotebookID.
+	Restore(ctx context.Context, note *models.Note) error
+	// Delete удаляет заметку безвозвратно вместе со всеми связанными данными.
+	Delete(ctx context.Context, note models.Note) error
+
+	// Revisions возвращает историю правок заметки, начиная с последней ревизии.
+	Revisions(ctx context.Context, noteID uint) ([]models.NoteRevision, error)
+	// FindRevision загружает ревизию заметки по номеру.
+	FindRevision(ctx context.Context, noteID uint, revision int) (models.NoteRevision, error)
+}
+
+type gormNoteRepository struct {
+	db *gorm.DB
+}
+
+// NewNoteRepository создает NoteRepository поверх подключения tx.
+func NewNoteRepository(tx *gorm.DB) NoteRepository {
+	return &gormNoteRepository{db: tx}
+}
+
+func (r *gormNoteRepository) FindByID(ctx context.Context, id uint, withTrashed bool) (models.Note, error) {
+	var note models.Note
+	query := r.db.WithContext(ctx)
+	if withTrashed {
+		query = query.Unscoped()
+	}
+	err := query.Preload("Tags").First(&note, id).Error
+	return note, translate(err)
+}
+
+func (r *gormNoteRepository) FindTrashed(ctx context.Context, id, userID uint) (models.Note, error) {
+	var note models.Note
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		First(&note).Error
+	return note, translate(err)
+}
+
+func (r *gormNoteRepository) SharePermission(ctx context.Context, noteID, userID uint) (string, error) {
+	var share models.NoteShare
+	err := r.db.WithContext(ctx).Where("note_id = ? AND user_id = ?", noteID, userID).First(&share).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return share.Permission, err
+}
+
+func (r *gormNoteRepository) NotebookOwned(ctx context.Context, userID, notebookID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Notebook{}).
+		Where("id = ? AND user_id = ?", notebookID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormNoteRepository) Create(ctx context.Context, note *models.Note, tags []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").Create(note).Error; err != nil {
+			return err
+		}
+		if err := recordRevision(tx, *note, note.UserID, nil); err != nil {
+			return err
+		}
+		note.Tags = []models.Tag{}
+		if len(tags) == 0 {
+			return nil
+		}
+		return replaceNoteTags(tx, note, tags)
+	})
+}
+
+func (r *gormNoteRepository) Update(ctx context.Context, note *models.Note, update NoteUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if update.Revision {
+			if err := lockNote(tx, note.ID); err != nil {
+				return err
+			}
+			if err := ensureInitialRevision(tx, note.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("Tags").Save(note).Error; err != nil {
+			return err
+		}
+		if update.Revision {
+			if err := recordRevision(tx, *note, update.EditorID, update.RestoredFrom); err != nil {
+				return err
+			}
+		}
+		if update.Tags == nil {
+			return nil
+		}
+		return replaceNoteTags(tx, note, update.Tags)
+	})
+}
+
+func (r *gormNoteRepository) Move(ctx context.Context, note *models.Note, notebookID *uint) error {
+	if err := r.db.WithContext(ctx).Model(note).Update("notebook_id", notebookID).Error; err != nil {
+		return err
+	}
+	note.NotebookID = notebookID
+	return nil
+}
+
+func (r *gormNoteRepository) Trash(ctx context.Context, note models.Note) error {
+	result := r.db.WithContext(ctx).Delete(&note)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormNoteRepository) Restore(ctx context.Context, note *models.Note) error {
+	err := r.db.WithContext(ctx).Unscoped().Model(note).Updates(map[string]interface{}{
+		"deleted_at":  nil,
+		"notebook_id": note.NotebookID,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Preload("Tags").First(note, note.ID).Error
+}
+
+func (r *gormNoteRepository) Delete(ctx context.Context, note models.Note) error {
+	return trash.DeleteNotes(r.db.WithContext(ctx), []uint{note.ID})
+}
+
+func (r *gormNoteRepository) Revisions(ctx context.Context, noteID uint) ([]models.NoteRevision, error) {
+	revisions := []models.NoteRevision{}
+	err := r.db.WithContext(ctx).Where("note_id = ?", noteID).Order("revision DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *gormNoteRepository) FindRevision(ctx context.Context, noteID uint, revision int) (models.NoteRevision, error) {
+	var found models.NoteRevision
+	err := r.db.WithContext(ctx).Where("note_id = ? AND revision = ?", noteID, revision).First(&found).Error
+	return found, translate(err)
+}
+
+// lockNote блокирует строку заметки до конца транзакции tx, так что изменения одной заметки
+// выполняются по очереди и не получают одинаковый номер ревизии. Вместо SELECT ... FOR UPDATE
+// используется запись: в SQLite только она сразу берет блокировку базы на запись.
+func lockNote(tx *gorm.DB, noteID uint) error {
+	return tx.Exec("UPDATE notes SET id = id WHERE id = ?", noteID).Error
+}
+
+// ensureInitialRevision сохраняет исходное состояние заметки, созданной до появления истории правок,
+// если у нее еще нет ревизий. Вызывается под lockNote, до сохранения нового текста.
+func ensureInitialRevision(tx *gorm.DB, noteID uint) error {
+	var count int64
+	if err := tx.Model(&models.NoteRevision{}).Where("note_id = ?", noteID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	var note models.Note
+	if err := tx.Unscoped().First(&note, noteID).Error; err != nil {
+		return translate(err)
+	}
+	return recordRevision(tx, note, note.UserID, nil)
+}
+
+// recordRevision сохраняет текущее состояние заметки как новую ревизию. Номер на единицу больше
+// последнего, поэтому при изменении существующей заметки вызывается под lockNote.
+func recordRevision(tx *gorm.DB, note models.Note, editorID uint, restoredFrom *int) error {
+	var last int
+	if err := tx.Model(&models.NoteRevision{}).
+		Where("note_id = ?", note.ID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.NoteRevision{
+		NoteID:       note.ID,
+		Revision:     last + 1,
+		Title:        note.Title,
+		Content:      note.Content,
+		UserID:       editorID,
+		RestoredFrom: restoredFrom,
+	}).Error
+}
+
+// replaceNoteTags заменяет метки заметки метками владельца с именами names, создавая недостающие.
+func replaceNoteTags(tx *gorm.DB, note *models.Note, names []string) error {
+	tags := []models.Tag{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = models.NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tag := models.Tag{Name: name, UserID: note.UserID}
+		if err := tx.Where(models.Tag{Name: name, UserID: note.UserID}).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+
+	if len(tags) == 0 {
+		note.Tags = []models.Tag{}
+		return tx.Model(note).Association("Tags").Clear()
+	}
+	if err := tx.Model(note).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+	note.Tags = tags
+	return nil
+}
@@ -0,0 +1,25 @@
+// Package repository отделяет хранение заметок и пользователей от правил работы с ними:
+// сервисы зависят только от интерфейсов NoteRepository и UserRepository, а реализации
+// на GORM работают с переданным при создании подключением.
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound возвращается, если запись не найдена.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate возвращается, если запись нарушает уникальный индекс.
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// translate заменяет ошибку GORM об отсутствии записи на ErrNotFound.
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
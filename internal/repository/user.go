@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// UserRepository хранит учетные записи пользователей.
+type UserRepository interface {
+	// FindByID загружает пользователя по ID.
+	FindByID(ctx context.Context, id uint) (models.User, error)
+	// Exists сообщает, существует ли пользователь с таким ID.
+	Exists(ctx context.Context, id uint) (bool, error)
+	// Update сохраняет заполненные поля профиля из input одним запросом; resetEmailVerification
+	// снимает отметку о подтверждении email. Занятые имя или email — ErrDuplicate.
+	Update(ctx context.Context, user *models.User, input models.UpdateUserInput, resetEmailVerification bool) error
+	// SetPassword сохраняет новый хеш пароля и завершает все сессии пользователя.
+	SetPassword(ctx context.Context, user *models.User, hash string) error
+	// Delete удаляет пользователя и завершает все его сессии.
+	Delete(ctx context.Context, id uint) error
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository создает UserRepository поверх подключения tx.
+func NewUserRepository(tx *gorm.DB) UserRepository {
+	return &gormUserRepository{db: tx}
+}
+
+func (r *gormUserRepository) FindByID(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	return user, translate(err)
+}
+
+func (r *gormUserRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUserRepository) Update(ctx context.Context, user *models.User, input models.UpdateUserInput, resetEmailVerification bool) error {
+	updates := map[string]interface{}{}
+	if input.Username != "" {
+		updates["username"] = input.Username
+	}
+	if input.Email != "" {
+		updates["email"] = input.Email
+	}
+	if resetEmailVerification {
+		updates["email_verified_at"] = nil
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Model(user).Updates(updates).Error
+	if db.IsDuplicate(r.db, err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *gormUserRepository) SetPassword(ctx context.Context, user *models.User, hash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", hash).Error; err != nil {
+			return err
+		}
+		return auth.RevokeAllForUser(tx, user.ID)
+	})
+}
+
+func (r *gormUserRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return auth.RevokeAllForUser(tx, id)
+	})
+}
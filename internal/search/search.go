@@ -0,0 +1,78 @@
+// Package search ищет заметки по заголовку и тексту. В Postgres используется tsvector, в SQLite —
+// виртуальная таблица FTS5; индексы создает миграция add_notes_search. Для SQLite приложение
+// собирается с тегом sqlite_fts5: go build -tags sqlite_fts5 (go test так же).
+package search
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrEmptyQuery возвращается, если в поисковом запросе нет ни одного слова.
+var ErrEmptyQuery = errors.New("пустой поисковый запрос")
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	ellipsis       = "…"
+)
+
+// Notes ищет заметки пользователя по заголовку и содержимому.
+// Результаты отсортированы по релевантности, совпадения в сниппетах обернуты в <mark>.
+func Notes(tx *gorm.DB, userID uint, query string, limit int) ([]models.NoteSearchResult, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	results := []models.NoteSearchResult{}
+	switch tx.Dialector.Name() {
+	case "postgres":
+		err := tx.Raw(`
+			SELECT n.*,
+				ts_rank_cd(n.search_vector, q) AS rank,
+				ts_headline('simple', n.title, q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS title_snippet,
+				ts_headline('simple', n.content, q, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=24, MinWords=8, FragmentDelimiter=" … "') AS content_snippet
+			FROM notes n, websearch_to_tsquery('simple', ?) q
+			WHERE n.user_id = ? AND n.deleted_at IS NULL AND n.search_vector @@ q
+			ORDER BY rank DESC, n.id DESC
+			LIMIT ?`, query, userID, limit).Scan(&results).Error
+		return results, err
+	case "sqlite":
+		return sqliteNotes(tx, userID, matchExpression(terms), limit)
+	default:
+		return nil, errors.New("полнотекстовый поиск не поддерживается для " + tx.Dialector.Name())
+	}
+}
+
+// matchExpression превращает пользовательский ввод в запрос MATCH:
+// каждое слово берется в кавычки, чтобы спецсимволы FTS не интерпретировались.
+func matchExpression(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// sqliteNotes ищет по индексу FTS5 notes_fts, который создает миграция add_notes_search.
+// bm25 в FTS5 возвращает тем меньшее значение, чем выше релевантность, поэтому знак меняется.
+func sqliteNotes(tx *gorm.DB, userID uint, match string, limit int) ([]models.NoteSearchResult, error) {
+	results := []models.NoteSearchResult{}
+	err := tx.Raw(`
+		SELECT n.*,
+			-bm25(notes_fts, 10.0, 1.0) AS rank,
+			highlight(notes_fts, 0, ?, ?) AS title_snippet,
+			snippet(notes_fts, 1, ?, ?, ?, 24) AS content_snippet
+		FROM notes_fts
+		JOIN notes n ON n.id = notes_fts.rowid
+		WHERE notes_fts MATCH ? AND n.user_id = ? AND n.deleted_at IS NULL
+		ORDER BY rank DESC, n.id DESC
+		LIMIT ?`,
+		highlightStart, highlightStop, highlightStart, highlightStop, ellipsis,
+		match, userID, limit).Scan(&results).Error
+	return results, err
+}
@@ -1,13 +1,13 @@
 package seed
 
 import (
-	"log"
+	"log/slog"
 
-	"github.com/heebit/notes-api/db"
 	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
 )
 
-func Load_notes() {
+func Load_notes(tx *gorm.DB) {
 notes := []models.Note{
 		{Title: "Первая заметка", Content: "Текст первой заметки", UserID: 1},
 		{Title: "Вторая заметка", Content: "Текст второй заметки", UserID: 1},	
@@ -16,15 +16,15 @@ notes := []models.Note{
 
 	for _, note := range notes {
 		var existingNote models.Note
-		if err := db.DB.Where("title = ? AND user_id = ?", note.Title, note.UserID).First(&existingNote).Error; err == nil {
-			log.Printf("Заметка с заголовком %s для пользователя %d уже существует, пропускаем создание.\n", note.Title, note.UserID)
+		if err := tx.Where("title = ? AND user_id = ?", note.Title, note.UserID).First(&existingNote).Error; err == nil {
+			slog.Info("Заметка уже существует, пропускаем создание", "title", note.Title, "owner_id", note.UserID)
 			continue
 		}
 		// Создание заметки, если она не существует
-		if err := db.DB.Create(&note).Error; err != nil {
-			log.Printf("Ошибка при создании заметки %s: %v\n", note.Title, err)
+		if err := tx.Create(&note).Error; err != nil {
+			slog.Error("Ошибка при создании заметки", "title", note.Title, "error", err)
 		} else {
-			log.Printf("Заметка %s успешно создана для пользователя %d.\n", note.Title, note.UserID)
+			slog.Info("Заметка успешно создана", "title", note.Title, "owner_id", note.UserID)
 		}
 	}
 	
@@ -1,22 +1,24 @@
 package seed
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
-	"github.com/heebit/notes-api/db"
 	"github.com/heebit/notes-api/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
 )
 
 func hashPassword(password string) string {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	hashed, err := utils.HashPassword(password, 14)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("Не удалось захешировать пароль тестового пользователя", "error", err)
+		os.Exit(1)
 	}
-	return string(hashed)
+	return hashed
 }
 
-func Load_users() {
+func Load_users(tx *gorm.DB) {
 	users := []models.User{
 		{Username: "testuser1", Email: "test1@example.com", Password: hashPassword("password123")},
 		{Username: "testuser2", Email: "test2@example.com", Password: hashPassword("password456")},
@@ -24,14 +26,14 @@ func Load_users() {
 
 	for _, user := range users {
 		var existingUser models.User
-		if err := db.DB.Where("username = ? OR email = ?", user.Username, user.Email).First(&existingUser).Error; err == nil {
-			log.Printf("Пользователь %s или %s уже существует, пропускаем создание.\n", user.Username, user.Email)
+		if err := tx.Where("username = ? OR email = ?", user.Username, user.Email).First(&existingUser).Error; err == nil {
+			slog.Info("Пользователь уже существует, пропускаем создание", "username", user.Username, "email", user.Email)
 			continue
 		}
-		if err := db.DB.Create(&user).Error; err != nil {
-			log.Printf("Ошибка при создании пользователя %s: %v\n", user.Username, err)
+		if err := tx.Create(&user).Error; err != nil {
+			slog.Error("Ошибка при создании пользователя", "username", user.Username, "error", err)
 		}else{
-			log.Printf("Пользователь %s успешно создан.\n", user.Username)
+			slog.Info("Пользователь успешно создан", "username", user.Username)
 		}
 	}
 
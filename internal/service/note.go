@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/heebit/notes-api/internal/repository"
+	"github.com/heebit/notes-api/models"
+)
+
+// NoteService управляет заметками с учетом прав доступа и истории правок.
+type NoteService struct {
+	notes repository.NoteRepository
+}
+
+// NewNoteService создает NoteService, хранящий заметки в notes.
+func NewNoteService(notes repository.NoteRepository) *NoteService {
+	return &NoteService{notes: notes}
+}
+
+// Get загружает заметку с метками и проверяет, что у пользователя есть доступ не ниже required.
+// Без доступа заметка считается ненайденной (ErrNoteNotFound), при недостаточном уровне
+// возвращается ErrForbidden.
+func (s *NoteService) Get(ctx context.Context, id, userID uint, required string) (models.Note, error) {
+	return s.get(ctx, id, userID, required, false)
+}
+
+// GetWithTrashed — Get, который находит и заметки в корзине.
+func (s *NoteService) GetWithTrashed(ctx context.Context, id, userID uint, required string) (models.Note, error) {
+	return s.get(ctx, id, userID, required, true)
+}
+
+func (s *NoteService) get(ctx context.Context, id, userID uint, required string, withTrashed bool) (models.Note, error) {
+	note, err := s.notes.FindByID(ctx, id, withTrashed)
+	if errors.Is(err, repository.ErrNotFound) {
+		return note, ErrNoteNotFound
+	}
+	if err != nil {
+		return note, err
+	}
+	permission, err := s.Permission(ctx, note, userID)
+	if err != nil {
+		return note, err
+	}
+	if permission == "" {
+		return note, ErrNoteNotFound
+	}
+	if models.PermissionLevel(permission) < models.PermissionLevel(required) {
+		return note, ErrForbidden
+	}
+	return note, nil
+}
+
+// Permission возвращает уровень доступа пользователя к заметке или пустую строку, если доступа нет.
+func (s *NoteService) Permission(ctx context.Context, note models.Note, userID uint) (string, error) {
+	if note.UserID == userID {
+		return models.PermissionOwner, nil
+	}
+	return s.notes.SharePermission(ctx, note.ID, userID)
+}
+
+// checkNotebook проверяет, что блокнот существует и принадлежит пользователю. nil означает корень.
+func (s *NoteService) checkNotebook(ctx context.Context, userID uint, notebookID *uint) error {
+	if notebookID == nil {
+		return nil
+	}
+	owned, err := s.notes.NotebookOwned(ctx, userID, *notebookID)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return ErrNotebookNotFound
+	}
+	return nil
+}
+
+// Create создает заметку пользователя с первой ревизией и метками из input.
+func (s *NoteService) Create(ctx context.Context, userID uint, input models.NoteInput) (models.Note, error) {
+	if err := s.checkNotebook(ctx, userID, input.NotebookID); err != nil {
+		return models.Note{}, err
+	}
+	note := models.Note{Title: input.Title, Content: input.Content, UserID: userID, NotebookID: input.NotebookID}
+	if err := s.notes.Create(ctx, &note, input.Tags); err != nil {
+		return models.Note{}, err
+	}
+	return note, nil
+}
+
+// Update меняет заголовок и текст заметки от имени editorID. Каждое изменение текста сохраняется
+// как ревизия; метки меняются, только если поле tags передано в запросе.
+func (s *NoteService) Update(ctx context.Context, note models.Note, editorID uint, input models.NoteInput) (models.Note, error) {
+	changed := note.Title != input.Title || note.Content != input.Content
+	note.Title = input.Title
+	note.Content = input.Content
+	err := s.notes.Update(ctx, &note, repository.NoteUpdate{
+		Revision: changed,
+		EditorID: editorID,
+		Tags:     input.Tags,
+	})
+	return note, err
+}
+
+// Move переносит заметку в блокнот её владельца (nil — в корень).
+func (s *NoteService) Move(ctx context.Context, note models.Note, notebookID *uint) (models.Note, error) {
+	if err := s.checkNotebook(ctx, note.UserID, notebookID); err != nil {
+		return note, err
+	}
+	err := s.notes.Move(ctx, &note, notebookID)
+	return note, err
+}
+
+// Delete перемещает заметку в корзину, а с permanent — удаляет её безвозвратно,
+// в том числе из корзины.
+func (s *NoteService) Delete(ctx context.Context, note models.Note, permanent bool) error {
+	if permanent {
+		return s.notes.Delete(ctx, note)
+	}
+	err := s.notes.Trash(ctx, note)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNoteNotFound
+	}
+	return err
+}
+
+// GetTrashed загружает заметку пользователя из корзины.
+func (s *NoteService) GetTrashed(ctx context.Context, id, userID uint) (models.Note, error) {
+	note, err := s.notes.FindTrashed(ctx, id, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return note, ErrNoteNotFound
+	}
+	return note, err
+}
+
+// Restore возвращает заметку из корзины. Если её блокнот тоже удален, заметка восстанавливается в корень.
+func (s *NoteService) Restore(ctx context.Context, note models.Note) (models.Note, error) {
+	if err := s.checkNotebook(ctx, note.UserID, note.NotebookID); errors.Is(err, ErrNotebookNotFound) {
+		note.NotebookID = nil
+	} else if err != nil {
+		return note, err
+	}
+	err := s.notes.Restore(ctx, &note)
+	return note, err
+}
+
+// Revisions возвращает историю правок заметки, начиная с последней ревизии.
+func (s *NoteService) Revisions(ctx context.Context, note models.Note) ([]models.NoteRevision, error) {
+	return s.notes.Revisions(ctx, note.ID)
+}
+
+// Revision загружает ревизию заметки по номеру.
+func (s *NoteService) Revision(ctx context.Context, note models.Note, revision int) (models.NoteRevision, error) {
+	found, err := s.notes.FindRevision(ctx, note.ID, revision)
+	if errors.Is(err, repository.ErrNotFound) {
+		return found, ErrRevisionNotFound
+	}
+	return found, err
+}
+
+// RestoreRevision возвращает заметке текст ревизии. Восстановление сохраняется как новая ревизия
+// со ссылкой на исходную.
+func (s *NoteService) RestoreRevision(ctx context.Context, note models.Note, editorID uint, revision models.NoteRevision) (models.Note, error) {
+	note.Title = revision.Title
+	note.Content = revision.Content
+	err := s.notes.Update(ctx, &note, repository.NoteUpdate{
+		Revision:     true,
+		EditorID:     editorID,
+		RestoredFrom: &revision.Revision,
+	})
+	return note, err
+}
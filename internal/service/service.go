@@ -0,0 +1,24 @@
+// Package service содержит правила работы с заметками и пользователями: кто и с каким уровнем
+// доступа может читать и менять заметку, когда сохраняется ревизия, как меняются профиль и пароль.
+// Хранение данных сервисы поручают интерфейсам пакета repository, а ошибки правил возвращают
+// значениями этого пакета, которые обработчики переводят в HTTP-ответы.
+package service
+
+import "errors"
+
+var (
+	// ErrNoteNotFound — заметка не найдена или у пользователя нет к ней доступа.
+	ErrNoteNotFound = errors.New("note not found")
+	// ErrRevisionNotFound — у заметки нет ревизии с таким номером.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrNotebookNotFound — блокнот не существует или принадлежит другому пользователю.
+	ErrNotebookNotFound = errors.New("notebook not found")
+	// ErrUserNotFound — пользователь не найден.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists — имя пользователя или email уже заняты.
+	ErrUserExists = errors.New("user already exists")
+	// ErrForbidden — уровня доступа пользователя недостаточно для операции.
+	ErrForbidden = errors.New("forbidden")
+	// ErrWrongPassword — текущий пароль пользователя указан неверно.
+	ErrWrongPassword = errors.New("wrong password")
+)
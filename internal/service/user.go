@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/heebit/notes-api/internal/repository"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserService управляет профилями пользователей.
+type UserService struct {
+	users repository.UserRepository
+}
+
+// NewUserService создает UserService, хранящий пользователей в users.
+func NewUserService(users repository.UserRepository) *UserService {
+	return &UserService{users: users}
+}
+
+// CheckAccess проверяет, что пользователь currentID может работать с профилем targetID:
+// доступен только собственный профиль. Чужой профиль — ErrForbidden, несуществующий — ErrUserNotFound.
+func (s *UserService) CheckAccess(ctx context.Context, currentID, targetID uint) error {
+	if currentID == targetID {
+		return nil
+	}
+	exists, err := s.users.Exists(ctx, targetID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return ErrForbidden
+}
+
+// Get загружает пользователя по ID.
+func (s *UserService) Get(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// Update меняет профиль пользователя. Новый адрес нужно подтвердить заново, поэтому при смене email
+// отметка о подтверждении снимается и emailChanged равен true. Занятые имя или email — ErrUserExists.
+func (s *UserService) Update(ctx context.Context, id uint, input models.UpdateUserInput) (user models.User, emailChanged bool, err error) {
+	user, err = s.Get(ctx, id)
+	if err != nil {
+		return user, false, err
+	}
+	emailChanged = input.Email != "" && input.Email != user.Email
+	err = s.users.Update(ctx, &user, input, emailChanged)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return user, false, ErrUserExists
+	}
+	if err != nil {
+		return user, false, err
+	}
+	return user, emailChanged, nil
+}
+
+// Delete удаляет пользователя и завершает все его сессии.
+func (s *UserService) Delete(ctx context.Context, id uint) error {
+	err := s.users.Delete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего пароля. Refresh-токены,
+// выпущенные со старым паролем, отзываются вместе со сменой.
+func (s *UserService) ChangePassword(ctx context.Context, id uint, oldPassword, newPassword string) error {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := utils.ComparePassword(user.Password, oldPassword); err != nil {
+		return ErrWrongPassword
+	}
+	hashedPassword, err := utils.HashPassword(newPassword, bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.users.SetPassword(ctx, &user, hashedPassword)
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// parentContextKey — ключ, под которым в операции GORM хранится контекст до начала span:
+// после запроса он возвращается в Statement, чтобы следующий запрос не стал дочерним для завершенного.
+const parentContextKey = "tracing:parent_context"
+
+// InstrumentGORM регистрирует колбэки, создающие span на каждый SQL-запрос подключения tx.
+// Spans становятся дочерними для span из контекста запроса (tx.WithContext). В span записывается
+// текст SQL с плейсхолдерами: значения параметров могут содержать пароли и токены.
+func InstrumentGORM(tx *gorm.DB) error {
+	callback := tx.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("tracing:before_create", startSpan("create")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", endSpan),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", startSpan("query")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", endSpan),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", startSpan("update")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", endSpan),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan("delete")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", startSpan("row")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", endSpan),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", startSpan("raw")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan),
+	)
+}
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		ctx, _ := otel.Tracer(tracerName).Start(parent, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(dbSystem(db.Dialector.Name()), semconv.DBOperationName(operation)),
+		)
+		db.InstanceSet(parentContextKey, parent)
+		db.Statement.Context = ctx
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	span := trace.SpanFromContext(db.Statement.Context)
+	if parent, ok := db.InstanceGet(parentContextKey); ok {
+		db.Statement.Context = parent.(context.Context)
+	}
+	if !span.IsRecording() {
+		span.End()
+		return
+	}
+	defer span.End()
+
+	attrs := []attribute.KeyValue{semconv.DBQueryText(db.Statement.SQL.String())}
+	if db.Statement.Table != "" {
+		attrs = append(attrs, semconv.DBCollectionName(db.Statement.Table))
+	}
+	if db.RowsAffected >= 0 {
+		attrs = append(attrs, attribute.Int64("db.rows_affected", db.RowsAffected))
+	}
+	span.SetAttributes(attrs...)
+	// Отсутствие записи — обычный результат поиска, а не ошибка
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+func dbSystem(dialect string) attribute.KeyValue {
+	switch dialect {
+	case "postgres":
+		return semconv.DBSystemNamePostgreSQL
+	case "sqlite":
+		return semconv.DBSystemNameSQLite
+	}
+	return semconv.DBSystemNameKey.String(dialect)
+}
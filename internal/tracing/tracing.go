@@ -0,0 +1,109 @@
+// Package tracing настраивает трассировку OpenTelemetry: экспорт spans (stdout или OTLP),
+// распространение контекста в заголовках W3C traceparent/tracestate и spans для HTTP-запросов
+// (Middleware) и SQL-запросов GORM (InstrumentGORM).
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/logging"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// tracerName — имя инструментирующей библиотеки в spans приложения.
+const tracerName = "github.com/heebit/notes-api"
+
+// untracedPaths — служебные маршруты, которые опрашиваются часто и трассировать которые незачем.
+var untracedPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// Setup настраивает глобальные TracerProvider и propagator по cfg и возвращает функцию, которая
+// отправляет накопленные spans и останавливает экспорт; её нужно вызвать при остановке сервера.
+// С экспортером none spans не создаются, но заголовки traceparent по-прежнему принимаются.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		var options []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("неизвестный экспортер трассировки %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать экспортер трассировки: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(PathRedactor()),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// PathRedactor возвращает SpanProcessor, который заменяет в атрибуте url.path серверных spans
+// секретные параметры маршрута (токен публичной ссылки) на [REDACTED], как и в журнале запросов.
+// Регистрируется раньше экспортера.
+func PathRedactor() sdktrace.SpanProcessor {
+	return pathRedactor{}
+}
+
+type pathRedactor struct{}
+
+func (pathRedactor) OnStart(_ context.Context, span sdktrace.ReadWriteSpan) {
+	var route, path string
+	for _, attr := range span.Attributes() {
+		switch attr.Key {
+		case semconv.HTTPRouteKey:
+			route = attr.Value.AsString()
+		case semconv.URLPathKey:
+			path = attr.Value.AsString()
+		}
+	}
+	if redacted := logging.RedactPath(route, path); redacted != path {
+		span.SetAttributes(semconv.URLPath(redacted))
+	}
+}
+
+func (pathRedactor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (pathRedactor) Shutdown(context.Context) error   { return nil }
+func (pathRedactor) ForceFlush(context.Context) error { return nil }
+
+// Middleware создает span на каждый HTTP-запрос с именем по шаблону маршрута и продолжает трассу
+// из заголовка traceparent. Span попадает в контекст запроса, так что запросы к базе с этим контекстом
+// становятся его дочерними spans. Применяется после RequestID и до остальных middleware.
+func Middleware(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !untracedPaths[c.FullPath()]
+	}))
+}
@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/heebit/notes-api/models"
+	"gorm.io/gorm"
+)
+
+// DeleteNotes безвозвратно удаляет заметки вместе с их метками, историей правок, выданными доступами и публичными ссылками.
+func DeleteNotes(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM note_tags WHERE note_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("note_id IN ?", ids).Delete(&models.NoteRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("note_id IN ?", ids).Delete(&models.NoteShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("note_id IN ?", ids).Delete(&models.ShareLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Note{}).Error
+	})
+}
+
+// Purge безвозвратно удаляет заметки, попавшие в корзину раньше before, и возвращает их количество.
+func Purge(tx *gorm.DB, before time.Time) (int, error) {
+	var ids []uint
+	if err := tx.Unscoped().Model(&models.Note{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return len(ids), DeleteNotes(tx, ids)
+}
+
+// RunPurger раз в interval очищает корзину от заметок, удаленных более retention назад.
+// Работает до отмены ctx.
+func RunPurger(ctx context.Context, tx *gorm.DB, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := Purge(tx, time.Now().Add(-retention)); err != nil {
+			slog.Error("Ошибка очистки корзины", "error", err)
+		} else if purged > 0 {
+			slog.Info("Корзина очищена", "purged", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
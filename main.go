@@ -1,13 +1,34 @@
+// Сервер Notes API. Собирается с тегом sqlite_fts5 (go build -tags sqlite_fts5), без него
+// в SQLite не работает полнотекстовый поиск.
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
 	"github.com/heebit/notes-api/db"
 	_ "github.com/heebit/notes-api/docs"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/internal/logging"
+	"github.com/heebit/notes-api/internal/mailer"
+	"github.com/heebit/notes-api/internal/metrics"
+	"github.com/heebit/notes-api/internal/ratelimit"
+	"github.com/heebit/notes-api/internal/repository"
 	"github.com/heebit/notes-api/internal/seed"
+	"github.com/heebit/notes-api/internal/service"
+	"github.com/heebit/notes-api/internal/tracing"
+	"github.com/heebit/notes-api/internal/trash"
+	"github.com/heebit/notes-api/middleware"
 	"github.com/heebit/notes-api/routes"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -21,22 +42,129 @@ import (
 // @BasePath /
 
 func main() {
-	db.Connect()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fatal("Ошибка миграции", err)
+		}
+		return
+	}
+	if err := run(); err != nil {
+		fatal("Ошибка сервера", err)
+	}
+}
+
+// run запускает сервер и работает до SIGINT или SIGTERM. Ошибки возвращаются, а не завершают процесс,
+// чтобы отложенные закрытие базы и остановка трассировки выполнялись в любом случае.
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+	logging.Setup(cfg.Log)
+
+	// SIGINT и SIGTERM запускают плавную остановку: сервер дорабатывает принятые запросы,
+	// фоновые задачи завершаются, и только затем закрывается база данных
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки трассировки: %w", err)
+	}
+	// Откладывается раньше закрытия базы, поэтому выполняется после него: spans последних запросов
+	// успевают уйти в экспортер
 	defer func() {
-		if db.SqlDB != nil {
-			if err := db.SqlDB.Close(); err != nil {
-				log.Fatalf("Ошибка закрытия базы данных: %v", err)
-			}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("Ошибка остановки трассировки", "error", err)
 		}
 	}()
 
-	if os.Getenv("APP_ENV")== "development" {
-		seed.Load_users()
-		seed.Load_notes()
+	database, err := db.Open(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	}
+	defer func() {
+		if err := db.Close(database); err != nil {
+			slog.Error("Ошибка закрытия базы данных", "error", err)
+		}
+	}()
+	if err := tracing.InstrumentGORM(database); err != nil {
+		return fmt.Errorf("ошибка подключения трассировки к базе данных: %w", err)
+	}
+	slog.Info("Успешное подключение к базе данных", "driver", database.Dialector.Name())
+
+	if cfg.Database.MigrateOnStart {
+		if err := migrateOnStart(ctx, database); err != nil {
+			return fmt.Errorf("ошибка применения миграций: %w", err)
+		}
 	}
 
-	r := gin.Default()
+	mail := mailer.New(cfg.Mail, database)
+	limits := ratelimit.NewStore(cfg.RateLimit.Store, database)
+
+	if cfg.App.Env == "development" {
+		seed.Load_users(database)
+		seed.Load_notes(database)
+	}
+
+	if err := auth.EnsureAdmins(database, cfg.App.AdminEmails); err != nil {
+		return fmt.Errorf("ошибка назначения администраторов: %w", err)
+	}
+
+	if sqlDB, err := database.DB(); err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	} else if err := metrics.RegisterDB(sqlDB, database.Dialector.Name()); err != nil {
+		return fmt.Errorf("ошибка регистрации метрик базы данных: %w", err)
+	}
+
+	// Фоновые задачи работают до отмены ctx. Ожидание откладывается позже закрытия базы,
+	// поэтому выполняется раньше него: база не закрывается посреди очистки корзины.
+	// stop отменяет ctx и тогда, когда run завершается из-за ошибки, а не по сигналу.
+	var workers sync.WaitGroup
+	background := func(task func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			task(ctx)
+		}()
+	}
+	defer func() {
+		stop()
+		workers.Wait()
+	}()
+
+	// Фоновая очистка корзины от заметок старше cfg.Trash.Retention
+	background(func(ctx context.Context) {
+		trash.RunPurger(ctx, database, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	})
+	// Истекшие refresh-токены и записи denylist больше не нужны для проверки токенов
+	background(func(ctx context.Context) {
+		auth.RunCleanup(ctx, database, time.Hour, cfg.Login.AttemptsRetention)
+	})
+	background(func(ctx context.Context) { ratelimit.RunCleanup(ctx, limits, 10*time.Minute) })
+	background(func(ctx context.Context) { metrics.RunStats(ctx, database) })
+
+	// Служебные сообщения gin (режим debug, список маршрутов) идут в общий журнал
+	gin.DebugPrintFunc = func(format string, values ...interface{}) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, _ int) {
+		slog.Debug("Маршрут", "method", method, "path", path, "handler", handler)
+	}
+
+	r, err := routes.NewEngine(cfg.Server)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки HTTP-сервера: %w", err)
+	}
+	r.Use(
+		middleware.RequestID(),
+		tracing.Middleware(cfg.Tracing.ServiceName),
+		middleware.RequestLogger(),
+		middleware.Metrics(),
+		middleware.Recovery(),
+	)
 
 	r.GET("/swagger/*any",
 		ginSwagger.WrapHandler(
@@ -45,9 +173,40 @@ func main() {
 		),
 	)
 
-	routes.NoteRoutes(r)
-	routes.AuthRoutes(r)
+	// Обработчики получают подключение к базе и сервисы явно, без глобальных переменных
+	notes := controllers.NewNoteHandler(database, service.NewNoteService(repository.NewNoteRepository(database)))
+	// Письма для сброса пароля отправляются после ответа; их дожидаемся до закрытия базы
+	authHandler := controllers.NewAuthHandler(database, mail, cfg)
+	defer authHandler.Wait()
+	users := controllers.NewUserHandler(database, service.NewUserService(repository.NewUserRepository(database)), mail, cfg)
+	authenticate := middleware.AuthMiddleware(database, cfg.JWT)
+	requireVerified := middleware.RequireVerifiedEmail(cfg.Auth.RequireEmailVerification)
+	limiter := middleware.NewRateLimiter(cfg.RateLimit, limits)
+
+	routes.NoteRoutes(r, notes, authenticate, requireVerified, limiter)
+	routes.TagRoutes(r, controllers.NewTagHandler(database), authenticate, requireVerified, limiter)
+	routes.NotebookRoutes(r, controllers.NewNotebookHandler(database), authenticate, requireVerified, limiter)
+	routes.AuthRoutes(r, authHandler, authenticate, limiter)
+	routes.UserRoutes(r, users, authenticate, limiter)
+	routes.PublicRoutes(r, notes, limiter)
+	routes.AdminRoutes(r, controllers.NewAdminHandler(database, mail, cfg), authenticate, limiter)
 
-	r.Run(":8080") // Запуск сервера на порту 8080
+	migrator, err := newMigrator(database)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки миграций: %w", err)
+	}
+	health := controllers.NewHealthHandler(database, migrator)
+	routes.HealthRoutes(r, health)
+	if cfg.Metrics.Enabled {
+		routes.MetricsRoutes(r)
+	}
+
+	return serve(ctx, cfg.Server, r, health.Drain)
+}
 
+// fatal записывает ошибку в журнал и завершает процесс. Вызывается только из main,
+// когда отложенные функции run уже выполнены.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
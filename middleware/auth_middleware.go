@@ -1,38 +1,101 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/auth"
+	"github.com/heebit/notes-api/internal/logging"
+	"github.com/heebit/notes-api/models"
+	"github.com/heebit/notes-api/utils"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(tx *gorm.DB, cfg config.JWTConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tx := tx.WithContext(c.Request.Context())
 		authHeader := c.GetHeader("Authorization")
 		if !strings.HasPrefix(authHeader, "Bearer ") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Требуется токен авторизации"})
 			return
 		}
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-		if err != nil || !token.Valid {
+		if strings.HasPrefix(tokenStr, models.PersonalAccessTokenPrefix) {
+			authenticatePersonalAccessToken(c, tx, tokenStr)
+			return
+		}
+		claims, err := utils.ParseAccessToken(cfg, tokenStr)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Неверный токен авторизации"})
 			return
 		}
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("user_id", uint(claims["user_id"].(float64)))
-			c.Next()
-		} else {
+		revoked, err := auth.IsAccessTokenRevoked(tx, claims.JTI)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки токена авторизации"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Токен авторизации отозван"})
+			return
+		}
+		if !setAccount(c, tx, claims.UserID) {
+			return
+		}
+		c.Set("token_jti", claims.JTI)
+		c.Set("token_expires_at", claims.ExpiresAt)
+		c.Next()
+	}
+}
+
+// authenticatePersonalAccessToken проверяет персональный токен доступа и кладет в контекст
+// user_id и сам токен — по нему RequireScope проверяет области действия.
+func authenticatePersonalAccessToken(c *gin.Context, tx *gorm.DB, tokenStr string) {
+	pat, err := auth.AuthenticatePersonalAccessToken(tx, tokenStr)
+	if errors.Is(err, auth.ErrInvalidAccessToken) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Неверный токен авторизации"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки токена авторизации"})
+		return
+	}
+	if !setAccount(c, tx, pat.UserID) {
+		return
+	}
+	c.Set(personalAccessTokenKey, pat)
+	c.Next()
+}
+
+// setAccount проверяет, что учетная запись существует и не заблокирована, и кладет в контекст
+// user_id, роль пользователя и признак подтвержденного email. При ошибке ответ уже отправлен.
+func setAccount(c *gin.Context, tx *gorm.DB, userID uint) bool {
+	var user models.User
+	if err := tx.Select("id", "role", "disabled_at", "email_verified_at").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Неверный токен авторизации"})
+			return false
 		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки токена авторизации"})
+		return false
+	}
+	if user.DisabledAt != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Учетная запись заблокирована"})
+		return false
 	}
+	c.Set("user_id", user.ID)
+	logging.SetUserID(c.Request.Context(), user.ID)
+	c.Set(userRoleKey, user.Role)
+	c.Set(emailVerifiedKey, user.EmailVerifiedAt != nil)
+	return true
 }
-// AuthMiddleware проверяет наличие и валидность JWT токена в заголовке запроса.
-// Если токен валиден, извлекает user_id и добавляет его в контекст запроса.
-// Если токен отсутствует или недействителен, возвращает ошибку 401 Unauthorized с соответствующим сообщением.
-// Этот middleware должен быть применен к защищенным маршрутам, чтобы обеспечить доступ только авторизованным пользователям.		
\ No newline at end of file
+// AuthMiddleware проверяет наличие и валидность JWT токена или персонального токена доступа (pat_...) в заголовке запроса.
+// Если токен валиден и не отозван (jti нет в denylist), а учетная запись не заблокирована, извлекает user_id и роль и добавляет их в контекст запроса
+// вместе с jti и сроком действия токена — они нужны для выхода из системы.
+// Если токен отсутствует, недействителен или отозван, возвращает ошибку 401 Unauthorized с соответствующим сообщением,
+// для заблокированной учетной записи — 403 Forbidden.
+// Этот middleware должен быть применен к защищенным маршрутам, чтобы обеспечить доступ только авторизованным пользователям.
+// Токены и учетные записи проверяются через подключение tx, переданное при создании middleware,
+// подпись JWT — ключом из cfg.
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/logging"
+	"github.com/heebit/notes-api/models"
+)
+
+// maxLoggedBody — сколько байт тела запроса попадает в журнал на уровне debug.
+const maxLoggedBody = 8 << 10
+
+// RequestLogger записывает в журнал каждый запрос: метод, шаблон маршрута, статус и время обработки.
+// Секретные параметры пути, например токен публичной ссылки, заменяются на [REDACTED].
+// Ответы 5xx пишутся с уровнем error, 4xx — warn, остальные — info. На уровне debug добавляется тело
+// запроса без паролей и токенов. Применяется после RequestID.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := c.Request.Context()
+
+		var body []byte
+		if c.Request.Body != nil && slog.Default().Enabled(ctx, slog.LevelDebug) {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody))
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", logging.RedactPath(c.FullPath(), c.Request.URL.Path),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		if len(body) > 0 {
+			attrs = append(attrs, "body", logging.RedactBody(c.ContentType(), body))
+		}
+		slog.Log(ctx, level, "HTTP-запрос", attrs...)
+	}
+}
+
+// readCloser читает из Reader, а закрывает исходное тело запроса.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Recovery перехватывает панику в обработчике, записывает её в журнал со стеком вызовов
+// и отвечает 500.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "Паника при обработке запроса", "panic", err, "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Внутренняя ошибка сервера"})
+	})
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/metrics"
+)
+
+// Metrics учитывает каждый запрос в метриках http_requests_total и http_request_duration_seconds.
+// Маршрут берется из шаблона (c.FullPath()); запросы к несуществующим путям попадают под route="unmatched",
+// чтобы сканеры не порождали новые временные ряды.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
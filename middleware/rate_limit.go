@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/internal/ratelimit"
+)
+
+// RateLimiter создает middleware ограничения частоты запросов; корзины всех групп маршрутов
+// хранятся в одном хранилище, переданном при запуске.
+type RateLimiter struct {
+	cfg   config.RateLimitConfig
+	store ratelimit.Store
+}
+
+// NewRateLimiter создает RateLimiter с настройками cfg поверх хранилища корзин store.
+func NewRateLimiter(cfg config.RateLimitConfig, store ratelimit.Store) *RateLimiter {
+	return &RateLimiter{cfg: cfg, store: store}
+}
+
+// RateLimit ограничивает частоту запросов к группе маршрутов group. Лимит fallback можно переопределить
+// настройкой RATE_LIMIT_<GROUP> (например, RATE_LIMIT_NOTES=600/m, см. config.RateLimitConfig),
+// а RATE_LIMIT_ENABLED=false отключает ограничения.
+//
+// После AuthMiddleware запросы считаются по пользователю, без авторизации — по IP клиента.
+// Ответ содержит заголовки X-RateLimit-Limit, X-RateLimit-Remaining и X-RateLimit-Reset (секунды до
+// полного восстановления лимита); при превышении возвращается 429 с Retry-After.
+func (l *RateLimiter) RateLimit(group string, fallback ratelimit.Limit) gin.HandlerFunc {
+	limit := fallback
+	if rate, ok := l.cfg.Limits[group]; ok {
+		limit = ratelimit.Limit{Requests: rate.Requests, Per: rate.Per}
+	}
+
+	return func(c *gin.Context) {
+		if !l.cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		key := group + ":ip:" + c.ClientIP()
+		if userID, exists := c.Get("user_id"); exists {
+			key = group + ":user:" + strconv.FormatUint(uint64(userID.(uint)), 10)
+		}
+
+		result, err := l.store.Take(c.Request.Context(), key, limit, time.Now())
+		if err != nil {
+			// Недоступность хранилища не должна останавливать API
+			slog.ErrorContext(c.Request.Context(), "Ошибка ограничения частоты запросов", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Слишком много запросов, повторите попытку позже"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/logging"
+)
+
+// RequestIDHeader — заголовок с идентификатором запроса.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey — ключ контекста gin с идентификатором запроса.
+const requestIDKey = "request_id"
+
+// maxRequestIDLength ограничивает длину принятого от клиента идентификатора.
+const maxRequestIDLength = 128
+
+// RequestID берет идентификатор запроса из заголовка X-Request-ID (например, от прокси) или создает новый.
+// Идентификатор возвращается в том же заголовке ответа, попадает во все записи журнала с контекстом
+// запроса и добавляется полем request_id в JSON-ответы с ошибкой. Применяется первым middleware.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		writer := &requestIDWriter{ResponseWriter: c.Writer, requestID: id}
+		c.Writer = writer
+		c.Next()
+		writer.flush()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID допускает только печатные ASCII-символы без пробелов, чтобы чужой идентификатор
+// не ломал журнал и заголовки.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDWriter придерживает JSON-ответы с ошибкой (статус 400 и выше), чтобы добавить в них request_id.
+// Остальные ответы пишутся сразу.
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+	body      *bytes.Buffer
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if w.body == nil && !w.bufferable() {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.body == nil {
+		w.body = &bytes.Buffer{}
+	}
+	return w.body.Write(data)
+}
+
+func (w *requestIDWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Size учитывает и придержанный ответ: журнал запросов читает размер до его отправки.
+func (w *requestIDWriter) Size() int {
+	if w.body != nil {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *requestIDWriter) bufferable() bool {
+	if w.Written() || w.Status() < 400 {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// flush отправляет придержанный ответ, добавив request_id, если это JSON-объект.
+func (w *requestIDWriter) flush() {
+	if w.body == nil {
+		return
+	}
+	data := w.body.Bytes()
+	w.body = nil
+	var response map[string]any
+	if json.Unmarshal(data, &response) == nil {
+		if _, ok := response[requestIDKey]; !ok {
+			response[requestIDKey] = w.requestID
+			if encoded, err := json.Marshal(response); err == nil {
+				data = encoded
+			}
+		}
+	}
+	w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userRoleKey — ключ контекста, под которым AuthMiddleware сохраняет роль пользователя.
+const userRoleKey = "user_role"
+
+// RequireRole пропускает запрос, только если роль пользователя входит в roles.
+// Применяется после AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString(userRoleKey)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Недостаточно прав"})
+	}
+}
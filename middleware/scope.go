@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/models"
+)
+
+// personalAccessTokenKey — ключ контекста, под которым AuthMiddleware сохраняет персональный токен.
+const personalAccessTokenKey = "personal_access_token"
+
+// RequireScope пропускает запрос, если он авторизован персональным токеном с областью scope.
+// Сессии, полученные через вход по паролю, ограничений по областям не имеют.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get(personalAccessTokenKey)
+		if !exists {
+			c.Next()
+			return
+		}
+		if pat, ok := value.(models.PersonalAccessToken); !ok || !pat.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Токену не хватает прав: " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession запрещает доступ по персональным токенам. Используется там, где токен не должен
+// расширять собственные права: управление токенами, безопасностью аккаунта.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get(personalAccessTokenKey); exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Операция недоступна для персональных токенов"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// emailVerifiedKey — ключ контекста, под которым AuthMiddleware сохраняет, подтвержден ли email пользователя.
+const emailVerifiedKey = "email_verified"
+
+// RequireVerifiedEmail отклоняет запросы пользователей с неподтвержденным email, если required
+// (REQUIRE_EMAIL_VERIFICATION). Применяется после AuthMiddleware.
+func RequireVerifiedEmail(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+		if !c.GetBool(emailVerifiedKey) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Подтвердите email, чтобы продолжить"})
+			return
+		}
+		c.Next()
+	}
+}
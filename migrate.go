@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heebit/notes-api/config"
+	"github.com/heebit/notes-api/db"
+	"github.com/heebit/notes-api/internal/migrate"
+	"github.com/heebit/notes-api/migrations"
+	"gorm.io/gorm"
+)
+
+const migrateUsage = "использование: migrate up | down | status | create <название>"
+
+// runMigrate выполняет подкоманду migrate. create только создает файлы в каталоге migrations
+// и не требует подключения к базе данных.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		paths, err := migrate.Create("migrations", args[1], time.Now())
+		for _, path := range paths {
+			fmt.Println("Создан файл", path)
+		}
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	database, err := db.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close(database)
+
+	migrator, err := newMigrator(database)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Применена миграция %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Новых миграций нет")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if reverted != nil && err == nil {
+			fmt.Printf("Откачена миграция %d_%s\n", reverted.Version, reverted.Name)
+		} else if err == nil {
+			fmt.Println("Нет примененных миграций")
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ВЕРСИЯ\tНАЗВАНИЕ\tПРИМЕНЕНА")
+		for _, status := range statuses {
+			appliedAt := "ожидает"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+	return errors.New(migrateUsage)
+}
+
+// newMigrator создает Migrator встроенных миграций для подключения database.
+func newMigrator(database *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := database.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, database.Dialector.Name(), migrations.FS)
+}
+
+// migrateOnStart применяет новые миграции при запуске сервера.
+func migrateOnStart(ctx context.Context, database *gorm.DB) error {
+	migrator, err := newMigrator(database)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		slog.InfoContext(ctx, "Применена миграция", "version", migration.Version, "name", migration.Name)
+	}
+	return err
+}
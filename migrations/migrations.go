@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL-миграции в бинарник. Для каждого драйвера базы данных
+// свой каталог: postgres и sqlite. Файлы в формате goose: <версия>_<название>.sql
+// с секциями "-- +goose Up" и "-- +goose Down"; применяет их internal/migrate.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE note_revisions (
+    id SERIAL PRIMARY KEY,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    restored_from INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_note_revisions_note_revision ON note_revisions (note_id, revision);
+
+-- +goose Down
+DROP TABLE IF EXISTS note_revisions;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE note_shares (
+    id SERIAL PRIMARY KEY,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(16) NOT NULL CHECK (permission IN ('viewer', 'editor')),
+    granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_note_shares_note_user ON note_shares (note_id, user_id);
+CREATE INDEX idx_note_shares_user_id ON note_shares (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS note_shares;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE share_links (
+    id SERIAL PRIMARY KEY,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    password_hash TEXT,
+    expires_at TIMESTAMP,
+    max_views INTEGER CHECK (max_views > 0),
+    view_count INTEGER NOT NULL DEFAULT 0,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_share_links_token_hash ON share_links (token_hash);
+CREATE INDEX idx_share_links_note_id ON share_links (note_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS share_links;
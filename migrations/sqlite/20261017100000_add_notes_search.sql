@@ -0,0 +1,41 @@
+-- +goose Up
+-- Индекс FTS4, который раньше создавался при запуске приложения, заменяется индексом FTS5.
+-- Модуль fts5 есть в go-sqlite3 только при сборке с тегом sqlite_fts5.
+DROP TRIGGER IF EXISTS notes_fts_ai;
+DROP TRIGGER IF EXISTS notes_fts_ad;
+DROP TRIGGER IF EXISTS notes_fts_au;
+DROP TABLE IF EXISTS notes_fts;
+
+-- Индекс с внешним содержимым: текст хранится только в notes, триггеры поддерживают индекс
+CREATE VIRTUAL TABLE notes_fts USING fts5(
+    title, content,
+    content = 'notes', content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER notes_fts_ai AFTER INSERT ON notes BEGIN
+    INSERT INTO notes_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER notes_fts_ad AFTER DELETE ON notes BEGIN
+    INSERT INTO notes_fts(notes_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER notes_fts_au AFTER UPDATE OF title, content ON notes BEGIN
+    INSERT INTO notes_fts(notes_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+    INSERT INTO notes_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+-- +goose StatementEnd
+
+INSERT INTO notes_fts(notes_fts) VALUES ('rebuild');
+
+-- +goose Down
+DROP TRIGGER IF EXISTS notes_fts_au;
+DROP TRIGGER IF EXISTS notes_fts_ad;
+DROP TRIGGER IF EXISTS notes_fts_ai;
+DROP TABLE IF EXISTS notes_fts;
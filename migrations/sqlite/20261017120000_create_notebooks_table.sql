@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE notebooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES notebooks(id) ON DELETE SET NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME NULL
+);
+
+CREATE INDEX idx_notebooks_user_id ON notebooks (user_id);
+CREATE INDEX idx_notebooks_parent_id ON notebooks (parent_id);
+
+-- SQLite не умеет удалять столбцы с внешним ключом, поэтому notebook_id добавляется без REFERENCES;
+-- перенос заметок при удалении блокнота выполняет приложение
+ALTER TABLE notes ADD COLUMN notebook_id INTEGER;
+CREATE INDEX idx_notes_notebook_id ON notes (notebook_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notes_notebook_id;
+ALTER TABLE notes DROP COLUMN notebook_id;
+DROP TABLE IF EXISTS notebooks;
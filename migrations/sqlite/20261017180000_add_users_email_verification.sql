@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+ALTER TABLE users ADD COLUMN verification_sent_at DATETIME;
+
+-- Существующие аккаунты создавались без подтверждения email, блокировать их не нужно
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN verification_sent_at;
+ALTER TABLE users DROP COLUMN email_verified_at;
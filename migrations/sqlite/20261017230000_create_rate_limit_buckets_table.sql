@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens REAL NOT NULL,
+    refilled_at DATETIME NOT NULL,
+    full_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_full_at ON rate_limit_buckets (full_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Области действия персональных токенов доступа.
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+)
+
+// PersonalAccessTokenPrefix отличает персональные токены от JWT в заголовке Authorization.
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken — долгоживущий токен для скриптов и интеграций с ограниченным набором прав.
+// Хранится только SHA-256 от токена; Prefix — его начало, чтобы пользователь мог узнать токен в списке.
+type PersonalAccessToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;not null"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope сообщает, разрешена ли токену область действия scope.
+func (t PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type PersonalAccessTokenInput struct {
+	Name      string     `json:"name" binding:"required,max=100" example:"backup script"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=notes:read notes:write" example:"notes:read"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+// CreatedPersonalAccessToken — ответ на создание токена, единственное место, где виден сам токен.
+type CreatedPersonalAccessToken struct {
+	PersonalAccessToken
+	Token string `json:"token" example:"pat_k3J9v0cX..."`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AdminUser — пользователь в административном API: вместе со статусом учетной записи
+// и количеством заметок, но без секретов.
+type AdminUser struct {
+	ID            uint       `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	EmailVerified bool       `json:"email_verified"`
+	MFAEnabled    bool       `json:"mfa_enabled"`
+	DisabledAt    *time.Time `json:"disabled_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	NoteCount     int64      `json:"note_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type UpdateRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=user admin" example:"admin"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// HealthResponse — ответ проверки живости процесса.
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// ReadinessResponse — ответ проверки готовности принимать запросы. Status равен "ok", только если
+// доступна база данных и применены все миграции.
+type ReadinessResponse struct {
+	Status     string           `json:"status" example:"ok"`
+	Database   string           `json:"database" example:"ok"`
+	Migrations MigrationsStatus `json:"migrations"`
+}
+
+// MigrationsStatus — состояние схемы базы данных: версия последней примененной миграции
+// и число еще не примененных.
+type MigrationsStatus struct {
+	Status  string `json:"status" example:"ok"`
+	Version int64  `json:"version" example:"20250101000000"`
+	Pending int    `json:"pending" example:"0"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Результаты попыток входа.
+const (
+	LoginResultSuccess       = "success"
+	LoginResultWrongPassword = "wrong_password"
+	LoginResultWrongCode     = "wrong_code"
+	LoginResultLockedOut     = "locked_out"
+)
+
+// LoginAttempt — запись журнала попыток входа. UserID пуст, если идентификатор не принадлежит
+// ни одному пользователю. Неудачные попытки с одного IP используются для ограничения перебора.
+type LoginAttempt struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     *uint     `json:"-" gorm:"index"`
+	Identifier string    `json:"identifier" gorm:"size:255;not null"`
+	IP         string    `json:"ip" gorm:"size:64;not null;index"`
+	Result     string    `json:"result" gorm:"size:20;not null" example:"wrong_password"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// RecoveryCode — хеш одноразового кода восстановления для входа без приложения-аутентификатора.
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// MFASetup — данные для добавления секрета в приложение-аутентификатор.
+type MFASetup struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Notes%20API:user1?secret=JBSWY3DPEHPK3PXP&issuer=Notes+API"`
+	// QRCode — PNG с QR-кодом otpauth_uri в виде data URI.
+	QRCode string `json:"qr_code" example:"data:image/png;base64,iVBORw0KGgo..."`
+}
+
+// RecoveryCodes — коды восстановления, показываются один раз.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"ab12c-de34f"`
+}
+
+// MFAChallenge — ответ на вход по паролю, когда включена двухфакторная аутентификация.
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	ExpiresIn   int    `json:"expires_in" example:"300"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type MFALoginInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code — код из приложения или один из кодов восстановления.
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type DisableMFAInput struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
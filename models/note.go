@@ -1,15 +1,45 @@
 package models
 
+import "time"
+
 type Note struct {
 	GormModelSwagger
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
-	UserID  uint   `json:"user_id"`
+	Title      string `json:"title" binding:"required"`
+	Content    string `json:"content" binding:"required"`
+	UserID     uint   `json:"user_id"`
+	NotebookID *uint  `json:"notebook_id" gorm:"index"`
+	Tags       []Tag  `json:"tags" gorm:"many2many:note_tags;constraint:OnDelete:CASCADE"`
 }
 
 type NoteSwagger struct {
-	ID      uint   `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	UserID  uint   `json:"user_id"`
+	ID         uint   `json:"id"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	UserID     uint   `json:"user_id"`
+	NotebookID *uint  `json:"notebook_id"`
+	Tags       []Tag  `json:"tags"`
+}
+
+// NoteInput — данные для создания и обновления заметки.
+// Если Tags не передан, метки заметки при обновлении не меняются; пустой массив снимает все метки.
+// NotebookID учитывается только при создании, для переноса используется POST /notes/{id}/move.
+type NoteInput struct {
+	Title      string   `json:"title" binding:"required"`
+	Content    string   `json:"content" binding:"required"`
+	Tags       []string `json:"tags" binding:"omitempty,max=20,dive,required,max=64" example:"работа,идеи"`
+	NotebookID *uint    `json:"notebook_id" example:"1"`
+}
+
+// NoteSearchResult — заметка, найденная полнотекстовым поиском, с рангом и подсвеченными фрагментами.
+type NoteSearchResult struct {
+	Note
+	Rank           float64 `json:"rank" example:"0.42"`
+	TitleSnippet   string  `json:"title_snippet" example:"Моя <mark>первая</mark> заметка"`
+	ContentSnippet string  `json:"content_snippet" example:"… содержимое моей <mark>первой</mark> заметки …"`
+}
+
+// TrashedNote — заметка в корзине с датой удаления.
+type TrashedNote struct {
+	Note
+	DeletedAt time.Time `json:"deleted_at" example:"2023-01-02T12:00:00Z"`
 }
@@ -0,0 +1,29 @@
+package models
+
+// Notebook — блокнот для группировки заметок. Блокноты могут быть вложены друг в друга.
+type Notebook struct {
+	GormModelSwagger
+	Name     string     `json:"name" gorm:"size:255;not null" example:"Работа"`
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	ParentID *uint      `json:"parent_id" gorm:"index" example:"1"`
+	Children []Notebook `json:"children,omitempty" gorm:"-"`
+}
+
+type NotebookInput struct {
+	Name     string `json:"name" binding:"required,max=255" example:"Работа"`
+	ParentID *uint  `json:"parent_id" example:"1"`
+}
+
+type RenameNotebookInput struct {
+	Name string `json:"name" binding:"required,max=255" example:"Проекты"`
+}
+
+// MoveNotebookInput — новый родитель блокнота; null переносит блокнот в корень.
+type MoveNotebookInput struct {
+	ParentID *uint `json:"parent_id" example:"2"`
+}
+
+// MoveNoteInput — блокнот, в который переносится заметка; null переносит заметку в корень.
+type MoveNoteInput struct {
+	NotebookID *uint `json:"notebook_id" example:"2"`
+}
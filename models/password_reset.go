@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PasswordResetToken — хеш одноразового токена сброса пароля.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// OutboxEmail — письмо, сохраненное локальным почтовым транспортом вместо отправки.
+type OutboxEmail struct {
+	ID        uint   `gorm:"primaryKey"`
+	To        string `gorm:"not null;index"`
+	Subject   string `gorm:"not null"`
+	Body      string `gorm:"type:text;not null"`
+	CreatedAt time.Time
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RateLimitBucket — корзина токенов ограничения частоты запросов для ключа (группа маршрутов и пользователь или IP).
+// FullAt — когда корзина заполнится полностью; после этого запись можно удалить.
+type RateLimitBucket struct {
+	Key        string    `gorm:"primaryKey;size:255"`
+	Tokens     float64   `gorm:"not null"`
+	RefilledAt time.Time `gorm:"not null"`
+	FullAt     time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NoteRevision — неизменяемый снимок заметки после очередного сохранения.
+type NoteRevision struct {
+	ID           uint      `json:"id" gorm:"primaryKey" example:"1"`
+	NoteID       uint      `json:"note_id" gorm:"not null;uniqueIndex:idx_note_revisions_note_revision" example:"1"`
+	Revision     int       `json:"revision" gorm:"not null;uniqueIndex:idx_note_revisions_note_revision" example:"2"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	UserID       uint      `json:"user_id" example:"1"`
+	RestoredFrom *int      `json:"restored_from,omitempty" example:"1"`
+	CreatedAt    time.Time `json:"created_at" example:"2023-01-01T12:00:00Z"`
+}
+
+// BeforeUpdate запрещает изменять сохраненные ревизии.
+func (NoteRevision) BeforeUpdate(*gorm.DB) error {
+	return errors.New("ревизии заметок нельзя изменять")
+}
+
+type RevisionDiff struct {
+	NoteID uint   `json:"note_id" example:"1"`
+	From   int    `json:"from" example:"1"`
+	To     int    `json:"to" example:"2"`
+	Diff   string `json:"diff" example:"--- revision 1\n+++ revision 2\n@@ -1 +1 @@\n-Старый заголовок\n+Новый заголовок\n"`
+}
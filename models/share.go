@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Уровни доступа к заметке. Каждый следующий уровень включает права предыдущего.
+const (
+	PermissionViewer = "viewer"
+	PermissionEditor = "editor"
+	PermissionOwner  = "owner"
+)
+
+// PermissionLevel возвращает числовой уровень доступа для сравнения прав (0 — нет доступа).
+func PermissionLevel(permission string) int {
+	switch permission {
+	case PermissionViewer:
+		return 1
+	case PermissionEditor:
+		return 2
+	case PermissionOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// NoteShare — доступ другого пользователя к заметке.
+type NoteShare struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	NoteID     uint      `json:"note_id" gorm:"not null;uniqueIndex:idx_note_shares_note_user"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_note_shares_note_user;index"`
+	Permission string    `json:"permission" gorm:"size:16;not null"`
+	GrantedBy  uint      `json:"granted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ShareNoteInput struct {
+	Identifier string `json:"identifier" binding:"required" example:"user2"`
+	Permission string `json:"permission" binding:"required,oneof=viewer editor" example:"editor"`
+}
+
+// NoteShareInfo — пользователь, которому открыт доступ к заметке.
+type NoteShareInfo struct {
+	UserID     uint      `json:"user_id" example:"2"`
+	Username   string    `json:"username" example:"user2"`
+	Permission string    `json:"permission" example:"editor"`
+	CreatedAt  time.Time `json:"created_at" example:"2023-01-01T12:00:00Z"`
+}
+
+// SharedNote — заметка другого пользователя, к которой у текущего пользователя есть доступ.
+type SharedNote struct {
+	Note
+	Permission    string `json:"permission" example:"viewer"`
+	OwnerUsername string `json:"owner_username" example:"user1"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ShareLink — публичная ссылка только для чтения на заметку.
+// Хранится только SHA-256 от токена: сам токен показывается один раз при создании.
+type ShareLink struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	NoteID       uint       `json:"note_id" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"has_password" gorm:"-"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxViews     *int       `json:"max_views"`
+	ViewCount    int        `json:"view_count" gorm:"not null;default:0"`
+	CreatedBy    uint       `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ShareLinkInput struct {
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-12-31T23:59:59Z"`
+	MaxViews  *int       `json:"max_views" binding:"omitempty,min=1" example:"10"`
+	Password  string     `json:"password" binding:"omitempty,min=4,max=72" example:"secret"`
+}
+
+// CreatedShareLink — ответ на создание ссылки, единственное место, где виден токен.
+type CreatedShareLink struct {
+	ShareLink
+	Token string `json:"token" example:"k3J9v0cX..."`
+	URL   string `json:"url" example:"/public/k3J9v0cX..."`
+}
+
+// PublicNote — заметка в том виде, в котором она открывается по публичной ссылке.
+type PublicNote struct {
+	Title     string    `json:"title" example:"Моя заметка"`
+	Content   string    `json:"content" example:"Содержание заметки"`
+	UpdatedAt time.Time `json:"updated_at" example:"2023-01-01T12:00:00Z"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Tag — метка пользователя. Имя уникально в пределах одного пользователя.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	Name      string    `json:"name" gorm:"size:64;not null;uniqueIndex:idx_tags_user_name" example:"работа"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2023-01-01T12:00:00Z"`
+}
+
+// NormalizeTagName приводит имя метки к единому виду: без лишних пробелов и в нижнем регистре.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// TagWithCount — метка с количеством заметок, к которым она привязана.
+type TagWithCount struct {
+	Tag
+	NoteCount int64 `json:"note_count" example:"3"`
+}
+
+type TagInput struct {
+	Name string `json:"name" binding:"required,max=64" example:"работа"`
+}
+
+type MergeTagInput struct {
+	TargetID uint `json:"target_id" binding:"required" example:"2"`
+}
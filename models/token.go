@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// RefreshToken — хеш выданного refresh-токена. Токены одного входа образуют семейство (FamilyID):
+// при ротации старый токен помечается использованным, а его повторное предъявление отзывает всё семейство.
+type RefreshToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	FamilyID  string    `gorm:"size:64;not null;index"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// RevokedToken — отозванный до истечения срока access-токен (denylist по jti).
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// TokenPair — ответ на вход и обновление токенов.
+type TokenPair struct {
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string `json:"refresh_token" example:"mF3x9Q..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+	// Token дублирует AccessToken для клиентов, написанных до появления refresh-токенов.
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token"`
+	// All завершает все сессии пользователя, а не только текущую.
+	All bool `json:"all"`
+}
@@ -1,20 +1,47 @@
 package models
 
+import "time"
+
+// Роли пользователей.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	GormModelSwagger
 	Username string `json:"username" gorm:"unique" binding:"required,min=3,max=30"`
 	Password string `json:"password" binding:"required,min=6"`
 	Email    string `json:"email" gorm:"unique" binding:"required,email" `
 	Notes    []Note `gorm:"foreignKey:UserID"`
+	// EmailVerifiedAt — когда пользователь подтвердил email; nil для неподтвержденных.
+	EmailVerifiedAt *time.Time `json:"-"`
+	// VerificationSentAt — когда отправлено последнее письмо подтверждения, для ограничения повторной отправки.
+	VerificationSentAt *time.Time `json:"-"`
+	// TOTPSecret — секрет двухфакторной аутентификации; до подтверждения кодом TOTPEnabledAt остается nil.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"-"`
+	// TOTPLastStep — шаг времени последнего принятого кода, защищает от повторного использования кода.
+	TOTPLastStep int64 `json:"-"`
+	// Role — роль пользователя: user или admin. Из запроса на регистрацию не заполняется.
+	Role string `json:"-" gorm:"size:20;not null;default:user"`
+	// DisabledAt — когда администратор заблокировал учетную запись; nil для активных.
+	DisabledAt *time.Time `json:"-"`
+	// FailedLogins — число неудачных попыток входа подряд; сбрасывается при успешном входе.
+	FailedLogins int `json:"-" gorm:"not null;default:0"`
+	// LockedUntil — до какого момента вход временно запрещен после серии неудачных попыток.
+	LockedUntil *time.Time `json:"-"`
 }
 
 type UserSwagger struct {
-	ID        uint   `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID            uint   `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Password      string `json:"password"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
 }
 
 type UpdateUserInput struct {
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+)
+
+// AdminRoutes — административный API, доступный только пользователям с ролью admin.
+func AdminRoutes(r *gin.Engine, h *controllers.AdminHandler, authenticate gin.HandlerFunc, limiter *middleware.RateLimiter) {
+	admin := r.Group("/admin").Use(authenticate, limiter.RateLimit("admin", apiRateLimit), middleware.RequireSession(), middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/users", h.AdminGetUsers)
+		admin.GET("/users/:id", h.AdminGetUser)
+		admin.PUT("/users/:id/role", h.AdminUpdateUserRole)
+		admin.POST("/users/:id/disable", h.AdminDisableUser)
+		admin.POST("/users/:id/enable", h.AdminEnableUser)
+		admin.POST("/users/:id/password-reset", h.AdminResetUserPassword)
+		admin.POST("/users/:id/unlock", h.AdminUnlockUser)
+		admin.GET("/users/:id/login-attempts", h.AdminGetLoginAttempts)
+	}
+}
@@ -3,12 +3,35 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
 )
 
-func AuthRoutes(r *gin.Engine) {
-	authGroup := r.Group("/auth")
+func AuthRoutes(r *gin.Engine, h *controllers.AuthHandler, authenticate gin.HandlerFunc, limiter *middleware.RateLimiter) {
+	authGroup := r.Group("/auth", limiter.RateLimit("auth", authRateLimit))
 	{
-		authGroup.POST("/register", controllers.Register)
-		authGroup.POST("/login", controllers.Login)
+		authGroup.POST("/register", h.Register)
+		authGroup.POST("/login", h.Login)
+		authGroup.POST("/login/mfa", h.LoginMFA)
+		authGroup.POST("/refresh", h.Refresh)
+		authGroup.POST("/logout", authenticate, h.Logout)
+		authGroup.POST("/password/forgot", h.ForgotPassword)
+		authGroup.POST("/password/reset", h.ResetPassword)
+		authGroup.GET("/verify", h.VerifyEmail)
+		authGroup.POST("/verify/resend", authenticate, middleware.RequireSession(), h.ResendVerification)
+	}
+
+	mfa := r.Group("/auth/mfa").Use(authenticate, limiter.RateLimit("auth", authRateLimit), middleware.RequireSession())
+	{
+		mfa.POST("/setup", h.SetupMFA)
+		mfa.POST("/enable", h.EnableMFA)
+		mfa.POST("/disable", h.DisableMFA)
+		mfa.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+
+	tokens := r.Group("/auth/tokens").Use(authenticate, limiter.RateLimit("auth", authRateLimit), middleware.RequireSession())
+	{
+		tokens.GET("/", h.GetPersonalAccessTokens)
+		tokens.POST("/", h.CreatePersonalAccessToken)
+		tokens.DELETE("/:id", h.RevokePersonalAccessToken)
 	}
 }
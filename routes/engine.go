@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/config"
+)
+
+// NewEngine создает gin.Engine без middleware. IP клиента (c.ClientIP) берется из X-Forwarded-For
+// только за прокси из cfg.TrustedProxies: на нем держатся ограничение частоты запросов и защита входа.
+func NewEngine(cfg config.ServerConfig) (*gin.Engine, error) {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
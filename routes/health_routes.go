@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+)
+
+// HealthRoutes — проверки для оркестратора и балансировщика. Ограничение частоты к ним не применяется:
+// их опрашивают часто и с одних и тех же адресов.
+func HealthRoutes(r *gin.Engine, h *controllers.HealthHandler) {
+	r.GET("/healthz", h.Healthz)
+	r.GET("/readyz", h.Readyz)
+}
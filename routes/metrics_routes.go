@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/metrics"
+)
+
+// MetricsRoutes — метрики Prometheus. Маршрут не требует авторизации, поэтому снаружи его стоит
+// закрыть на уровне сети или прокси либо отключить через METRICS_ENABLED=false.
+func MetricsRoutes(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
@@ -4,15 +4,34 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/heebit/notes-api/internal/controllers"
 	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
 )
 
-func NoteRoutes(r *gin.Engine) {
-	note := r.Group("/notes").Use(middleware.AuthMiddleware())
+func NoteRoutes(r *gin.Engine, h *controllers.NoteHandler, authenticate, requireVerified gin.HandlerFunc, limiter *middleware.RateLimiter) {
+	read := middleware.RequireScope(models.ScopeNotesRead)
+	write := middleware.RequireScope(models.ScopeNotesWrite)
+
+	note := r.Group("/notes").Use(authenticate, limiter.RateLimit("notes", apiRateLimit), requireVerified)
 	{
-		note.GET("/", controllers.GetNotes)
-		note.GET("/:id", controllers.GetNote)
-		note.POST("/", controllers.CreateNote)
-		note.DELETE("/:id", controllers.DeleteNote)
-		note.PUT("/:id", controllers.UpdateNote)
+		note.GET("/", read, h.GetNotes)
+		note.GET("/search", read, h.SearchNotes)
+		note.GET("/trash", read, h.GetTrash)
+		note.GET("/shared-with-me", read, h.GetSharedWithMe)
+		note.GET("/:id", read, h.GetNote)
+		note.POST("/", write, h.CreateNote)
+		note.DELETE("/:id", write, h.DeleteNote)
+		note.PUT("/:id", write, h.UpdateNote)
+		note.POST("/:id/move", write, h.MoveNote)
+		note.POST("/:id/restore", write, h.RestoreNote)
+		note.GET("/:id/revisions", read, h.GetNoteRevisions)
+		note.GET("/:id/revisions/diff", read, h.DiffNoteRevisions)
+		note.GET("/:id/revisions/:rev", read, h.GetNoteRevision)
+		note.POST("/:id/revisions/:rev/restore", write, h.RestoreNoteRevision)
+		note.GET("/:id/shares", read, h.GetNoteShares)
+		note.POST("/:id/shares", write, h.ShareNote)
+		note.DELETE("/:id/shares/:userId", write, h.RevokeNoteShare)
+		note.GET("/:id/links", read, h.GetShareLinks)
+		note.POST("/:id/links", write, h.CreateShareLink)
+		note.DELETE("/:id/links/:linkId", write, h.RevokeShareLink)
 	}
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+)
+
+func NotebookRoutes(r *gin.Engine, h *controllers.NotebookHandler, authenticate, requireVerified gin.HandlerFunc, limiter *middleware.RateLimiter) {
+	read := middleware.RequireScope(models.ScopeNotesRead)
+	write := middleware.RequireScope(models.ScopeNotesWrite)
+
+	notebook := r.Group("/notebooks").Use(authenticate, limiter.RateLimit("notebooks", apiRateLimit), requireVerified)
+	{
+		notebook.GET("/", read, h.GetNotebooks)
+		notebook.GET("/:id", read, h.GetNotebook)
+		notebook.POST("/", write, h.CreateNotebook)
+		notebook.PUT("/:id", write, h.RenameNotebook)
+		notebook.POST("/:id/move", write, h.MoveNotebook)
+		notebook.DELETE("/:id", write, h.DeleteNotebook)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
+)
+
+// PublicRoutes — маршруты без авторизации: просмотр заметок по публичным ссылкам.
+func PublicRoutes(r *gin.Engine, h *controllers.NoteHandler, limiter *middleware.RateLimiter) {
+	public := r.Group("/public").Use(limiter.RateLimit("public", publicRateLimit))
+	{
+		public.GET("/:token", h.GetPublicNote)
+		public.POST("/:token", h.GetPublicNote)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/heebit/notes-api/internal/ratelimit"
+)
+
+// Лимиты частоты запросов по умолчанию; переопределяются настройками RATE_LIMIT_<GROUP>.
+var (
+	// authRateLimit — регистрация, вход и восстановление пароля: запросы дорогие (bcrypt) и привлекают перебор.
+	authRateLimit = ratelimit.Limit{Requests: 20, Per: time.Minute}
+	// apiRateLimit — работа авторизованного пользователя с заметками и профилем.
+	apiRateLimit = ratelimit.Limit{Requests: 300, Per: time.Minute}
+	// publicRateLimit — просмотр заметок по публичным ссылкам, в том числе подбор пароля ссылки.
+	publicRateLimit = ratelimit.Limit{Requests: 60, Per: time.Minute}
+)
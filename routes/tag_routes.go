@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
+	"github.com/heebit/notes-api/models"
+)
+
+func TagRoutes(r *gin.Engine, h *controllers.TagHandler, authenticate, requireVerified gin.HandlerFunc, limiter *middleware.RateLimiter) {
+	read := middleware.RequireScope(models.ScopeNotesRead)
+	write := middleware.RequireScope(models.ScopeNotesWrite)
+
+	tag := r.Group("/tags").Use(authenticate, limiter.RateLimit("tags", apiRateLimit), requireVerified)
+	{
+		tag.GET("/", read, h.GetTags)
+		tag.POST("/", write, h.CreateTag)
+		tag.PUT("/:id", write, h.RenameTag)
+		tag.POST("/:id/merge", write, h.MergeTag)
+		tag.DELETE("/:id", write, h.DeleteTag)
+	}
+}
@@ -3,16 +3,19 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/heebit/notes-api/internal/controllers"
+	"github.com/heebit/notes-api/middleware"
 )
 
-func UserRoutes(r *gin.Engine) {
-	user := r.Group("/users")
+func UserRoutes(r *gin.Engine, h *controllers.UserHandler, authenticate gin.HandlerFunc, limiter *middleware.RateLimiter) {
+	user := r.Group("/users").Use(authenticate, limiter.RateLimit("users", apiRateLimit), middleware.RequireSession())
 	{
-		user.GET("/", controllers.GetUsers)
-		user.GET("/:id", controllers.GetUser)
-		user.PUT("/:id", controllers.UpdateUser)
-		user.PUT("/me/password", controllers.ChangePassword)
-		user.DELETE("/:id", controllers.DeleteUser)
-
+		user.GET("/me", h.GetUser)
+		user.PUT("/me", h.UpdateUser)
+		user.DELETE("/me", h.DeleteUser)
+		user.PUT("/me/password", h.ChangePassword)
+		user.GET("/me/login-attempts", h.GetLoginAttempts)
+		user.GET("/:id", h.GetUser)
+		user.PUT("/:id", h.UpdateUser)
+		user.DELETE("/:id", h.DeleteUser)
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/heebit/notes-api/config"
+)
+
+// serve обслуживает запросы handler, пока не отменен ctx, после чего перестает принимать новые
+// соединения и ждет завершения принятых запросов не дольше cfg.ShutdownTimeout.
+// Перед остановкой вызывается onShutdown — например, чтобы /readyz начал отвечать 503, — и сервер
+// еще cfg.DrainDelay продолжает принимать запросы, пока балансировщик не заметит неготовность.
+func serve(ctx context.Context, cfg config.ServerConfig, handler http.Handler, onShutdown func()) error {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("Сервер запущен", "addr", cfg.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	if onShutdown != nil {
+		onShutdown()
+	}
+	if cfg.DrainDelay > 0 {
+		slog.Info("Остановка сервера, ожидание исключения из балансировки", "delay", cfg.DrainDelay.String())
+		select {
+		case <-time.After(cfg.DrainDelay):
+		case err := <-errs:
+			return err
+		}
+	}
+
+	slog.Info("Остановка сервера, ожидание завершения запросов", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("Сервер остановлен")
+	return nil
+}
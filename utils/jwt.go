@@ -1,22 +1,150 @@
 package utils
 
 import (
-	"os"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/heebit/notes-api/config"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET"))
+// AccessClaims — данные, извлекаемые из access-токена.
+type AccessClaims struct {
+	UserID    uint
+	JTI       string
+	ExpiresAt time.Time
+}
 
-func GenerateJWT(userID uint) (string, error) {
-	claims := jwt.MapClaims{
+// RandomToken возвращает случайную строку из n байт в base64url.
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken возвращает SHA-256 токена в hex. В базе хранятся только хеши токенов.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAccessToken выпускает access-токен со сроком cfg.AccessTTL и уникальным jti,
+// по которому токен можно отозвать до истечения срока.
+func GenerateAccessToken(cfg config.JWTConfig, userID uint) (string, AccessClaims, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", AccessClaims{}, err
+	}
+	now := time.Now()
+	claims := AccessClaims{UserID: userID, JTI: jti, ExpiresAt: now.Add(cfg.AccessTTL)}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     claims.ExpiresAt.Unix(),
+	})
+	signed, err := token.SignedString([]byte(cfg.Secret))
+	return signed, claims, err
+}
+
+// ParseAccessToken проверяет подпись и срок действия токена и извлекает из него claims.
+// jti может отсутствовать у токенов, выпущенных до появления отзыва.
+func ParseAccessToken(cfg config.JWTConfig, tokenStr string) (AccessClaims, error) {
+	var result AccessClaims
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		return []byte(cfg.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return result, errors.New("invalid token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return result, errors.New("invalid claims")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return result, errors.New("missing user_id")
 	}
+	result.UserID = uint(userID)
+	result.JTI, _ = claims["jti"].(string)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.ExpiresAt = exp.Time
+	}
+	return result, nil
+}
+
+// Назначения служебных токенов. Они подписываются тем же ключом, что и access-токены,
+// поэтому назначение проверяется явно, а user_id в них не кладется.
+const (
+	emailVerificationPurpose = "email_verification"
+	mfaPurpose               = "mfa"
+)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+// signPurposeToken подписывает служебный токен с назначением purpose для пользователя userID.
+func signPurposeToken(cfg config.JWTConfig, purpose string, userID uint, ttl time.Duration, extra jwt.MapClaims) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":     strconv.FormatUint(uint64(userID), 10),
+		"purpose": purpose,
+		"exp":     time.Now().Add(ttl).Unix(),
+	}
+	for key, value := range extra {
+		claims[key] = value
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Secret))
+}
+
+// parsePurposeToken проверяет подпись, срок и назначение служебного токена.
+func parsePurposeToken(cfg config.JWTConfig, purpose, tokenStr string) (uint, jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		return []byte(cfg.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return 0, nil, errors.New("invalid token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purpose {
+		return 0, nil, errors.New("invalid token purpose")
+	}
+	sub, _ := claims.GetSubject()
+	userID, err := strconv.ParseUint(sub, 10, 32)
+	if err != nil {
+		return 0, nil, errors.New("invalid subject")
+	}
+	return uint(userID), claims, nil
 }
 
-//Шаг 3: Middleware для авторизации
\ No newline at end of file
+// GenerateEmailVerificationToken подписывает токен подтверждения email со сроком ttl
+// (EMAIL_VERIFICATION_TTL). Адрес входит в токен, поэтому после смены email старые ссылки перестают действовать.
+func GenerateEmailVerificationToken(cfg config.JWTConfig, ttl time.Duration, userID uint, email string) (string, error) {
+	return signPurposeToken(cfg, emailVerificationPurpose, userID, ttl, jwt.MapClaims{"email": email})
+}
+
+// ParseEmailVerificationToken проверяет токен подтверждения email и возвращает ID пользователя и адрес.
+func ParseEmailVerificationToken(cfg config.JWTConfig, tokenStr string) (uint, string, error) {
+	userID, claims, err := parsePurposeToken(cfg, emailVerificationPurpose, tokenStr)
+	if err != nil {
+		return 0, "", err
+	}
+	email, _ := claims["email"].(string)
+	return userID, email, nil
+}
+
+// GenerateMFAToken выпускает токен со сроком ttl (MFA_TOKEN_TTL), подтверждающий, что пользователь
+// прошел первый шаг входа (пароль).
+func GenerateMFAToken(cfg config.JWTConfig, ttl time.Duration, userID uint) (string, error) {
+	return signPurposeToken(cfg, mfaPurpose, userID, ttl, nil)
+}
+
+// ParseMFAToken проверяет токен первого шага входа и возвращает ID пользователя.
+func ParseMFAToken(cfg config.JWTConfig, tokenStr string) (uint, error) {
+	userID, _, err := parsePurposeToken(cfg, mfaPurpose, tokenStr)
+	return userID, err
+}
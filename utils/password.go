@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/heebit/notes-api/internal/metrics"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword хеширует пароль bcrypt со стоимостью cost. Время хеширования попадает в метрику
+// password_hash_duration_seconds.
+func HashPassword(password string, cost int) (string, error) {
+	defer metrics.ObservePasswordHash("hash", time.Now())
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	return string(hashed), err
+}
+
+// ComparePassword проверяет, что password соответствует хешу bcrypt hash; nil означает совпадение.
+func ComparePassword(hash, password string) error {
+	defer metrics.ObservePasswordHash("compare", time.Now())
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}